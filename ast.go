@@ -0,0 +1,243 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"minitalk/tokens"
+)
+
+// astNode describes how a statement is evaluated: messages are sent strictly
+// left to right and every argument is a single primary, so each message node
+// has its receiver as the first child.
+type astNode struct {
+	label    string
+	children []*astNode
+}
+
+func (n *astNode) write(b *strings.Builder, depth int) {
+	b.WriteString(strings.Repeat("  ", depth) + n.label + "\n")
+	for _, child := range n.children {
+		child.write(b, depth+1)
+	}
+}
+
+type astParser struct {
+	toks []tokens.Token
+	pos  int
+}
+
+func (p *astParser) peek(offset int) (tokens.Token, bool) {
+	if p.pos+offset >= len(p.toks) {
+		return tokens.Token{}, false
+	}
+	return p.toks[p.pos+offset], true
+}
+
+func (p *astParser) is(offset int, types ...tokens.TokenType) bool {
+	tok, ok := p.peek(offset)
+	if !ok {
+		return false
+	}
+	for _, t := range types {
+		if tok.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *astParser) unexpected() error {
+	if tok, ok := p.peek(0); ok {
+		return fmt.Errorf("unexpected %s", tok.Value)
+	}
+	return fmt.Errorf("unexpected end of input")
+}
+
+func (p *astParser) statements(end tokens.TokenType) ([]*astNode, error) {
+	nodes := []*astNode{}
+	for p.pos < len(p.toks) && !p.is(0, end) {
+		if p.is(0, tokens.Period) {
+			p.pos++
+			continue
+		}
+		node, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if !p.is(0, tokens.Period, end) && p.pos < len(p.toks) {
+			return nil, p.unexpected()
+		}
+	}
+	return nodes, nil
+}
+
+func (p *astParser) statement() (*astNode, error) {
+	if p.is(0, tokens.Identifier) && p.is(1, tokens.Assignment) {
+		name := p.toks[p.pos].Value
+		p.pos += 2
+		value, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		return &astNode{"Assign " + name, []*astNode{value}}, nil
+	}
+	receiver, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	node, err := p.messages(receiver)
+	if err != nil {
+		return nil, err
+	}
+	if !p.is(0, tokens.Semicolon) {
+		return node, nil
+	}
+	cascade := &astNode{"Cascade", []*astNode{node}}
+	for p.is(0, tokens.Semicolon) {
+		p.pos++
+		message, err := p.messages(nil)
+		if err != nil {
+			return nil, err
+		}
+		if message == nil {
+			return nil, p.unexpected()
+		}
+		cascade.children = append(cascade.children, message)
+	}
+	return cascade, nil
+}
+
+// messages parses the chain sent to receiver; in a cascade receiver is nil and
+// the chain starts from the cascade's receiver.
+func (p *astParser) messages(receiver *astNode) (*astNode, error) {
+	node := receiver
+	wrap := func(label string, args ...*astNode) {
+		children := args
+		if node != nil {
+			children = append([]*astNode{node}, args...)
+		}
+		node = &astNode{label, children}
+	}
+	for p.pos < len(p.toks) {
+		tok := p.toks[p.pos]
+		switch {
+		case tok.Type == tokens.Colon && p.is(1, tokens.Identifier) && p.is(2, tokens.Colon):
+			// A colon in front of a keyword, as in 1:to:5, is ignored.
+			p.pos++
+		case tok.Type == tokens.Identifier && p.is(1, tokens.Colon):
+			selector := ""
+			args := []*astNode{}
+			for p.is(0, tokens.Identifier) && p.is(1, tokens.Colon) {
+				selector += p.toks[p.pos].Value + ":"
+				p.pos += 2
+				arg, err := p.primary()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+			}
+			wrap("Keyword "+selector, args...)
+		case tok.Type == tokens.Identifier:
+			p.pos++
+			wrap("Unary " + tok.Value)
+		case isBinaryOperator(tok.Type):
+			p.pos++
+			arg, err := p.primary()
+			if err != nil {
+				return nil, err
+			}
+			wrap("Binary "+tok.Value, arg)
+		default:
+			return node, nil
+		}
+	}
+	return node, nil
+}
+
+func (p *astParser) primary() (*astNode, error) {
+	tok, ok := p.peek(0)
+	if !ok {
+		return nil, p.unexpected()
+	}
+	switch tok.Type {
+	case tokens.Integer, tokens.Float, tokens.RadixNumber, tokens.String, tokens.Symbol, tokens.Character,
+		tokens.Array, tokens.ByteArray, tokens.Nil, tokens.True, tokens.False:
+		p.pos++
+		return &astNode{"Literal " + tok.Value, nil}, nil
+	case tokens.Plus, tokens.Minus:
+		signs := ""
+		for p.is(0, tokens.Plus, tokens.Minus) {
+			signs += p.toks[p.pos].Value
+			p.pos++
+		}
+		if p.is(0, tokens.Integer, tokens.Float, tokens.RadixNumber) {
+			p.pos++
+			return &astNode{"Literal " + signs + p.toks[p.pos-1].Value, nil}, nil
+		}
+	case tokens.Identifier:
+		p.pos++
+		return &astNode{"Variable " + tok.Value, nil}, nil
+	case tokens.LParen:
+		p.pos++
+		inner, err := p.statements(tokens.RParen)
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(0, tokens.RParen) {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		return &astNode{"Parens", inner}, nil
+	case tokens.LBracket:
+		p.pos++
+		label := "Block"
+		for p.is(0, tokens.Colon) && p.is(1, tokens.Identifier) {
+			label += " :" + p.toks[p.pos+1].Value
+			p.pos += 2
+		}
+		if p.is(0, tokens.Pipe) {
+			p.pos++
+		}
+		body, err := p.statements(tokens.RBracket)
+		if err != nil {
+			return nil, err
+		}
+		if !p.is(0, tokens.RBracket) {
+			return nil, p.unexpected()
+		}
+		p.pos++
+		return &astNode{label, body}, nil
+	}
+	return nil, p.unexpected()
+}
+
+// program parses every statement of p.toks; on failure p.pos is left at the
+// offending token.
+func (p *astParser) program() ([]*astNode, error) {
+	statements, err := p.statements(tokens.Illegal)
+	if err == nil && p.pos < len(p.toks) {
+		err = p.unexpected()
+	}
+	return statements, err
+}
+
+func dumpAST(input string) (string, error) {
+	var toks []tokens.Token
+	for _, tok := range tokens.Lex(input) {
+		if tok.Type != tokens.Whitespace && tok.Type != tokens.Comment {
+			toks = append(toks, tok)
+		}
+	}
+	p := &astParser{toks: toks}
+	statements, err := p.program()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, statement := range statements {
+		statement.write(&b, 0)
+	}
+	return b.String(), nil
+}
@@ -1,14 +1,19 @@
 package classes
 
 import (
-	"os"
-	"path/filepath"
-
 	"minitalk/types"
 	"minitalk/types/core"
 )
 
 func NewDiskClass() *core.Object {
+	return newDisk(osFileSystem{})
+}
+
+func NewMemoryDiskClass() *core.Object {
+	return newDisk(newMemFileSystem())
+}
+
+func newDisk(fsys fileSystem) *core.Object {
 	obj := core.NewObject("", "Disk")
 
 	obj.Set("referenceTo", func(args core.Object) interface{} {
@@ -16,8 +21,8 @@ func NewDiskClass() *core.Object {
 			return nil
 		}
 		rawPath := args.Self.(string)
-		cleanPath := filepath.Clean(rawPath)
-		return *NewFileClass(cleanPath)
+		cleanPath := fsys.Paths().clean(rawPath)
+		return *newFile(fsys, cleanPath)
 	})
 
 	obj.Set("ls", func(args core.Object) interface{} {
@@ -28,26 +33,66 @@ func NewDiskClass() *core.Object {
 
 		var targetDir string
 		if rawPath == "." {
-			cwd, err := os.Getwd()
+			cwd, err := fsys.Getwd()
 			if err != nil {
-				return types.NewArrayObject([]*core.Object{}).Object
+				return fileSystemError(err)
 			}
 			targetDir = cwd
 		} else {
-			targetDir = filepath.Clean(rawPath)
+			targetDir = fsys.Paths().clean(rawPath)
 		}
 
-		entries, err := os.ReadDir(targetDir)
+		entries, err := fsys.ReadDir(targetDir)
 		if err != nil {
-			return types.NewArrayObject([]*core.Object{}).Object
+			return fileSystemError(err)
 		}
 
-		var arr []*core.Object
+		arr := []*core.Object{}
 		for _, e := range entries {
 			arr = append(arr, &types.NewStringObject(e.Name()).Object)
 		}
 		return types.NewArrayObject(arr).Object
 	})
 
+	obj.Set("workingDirectory", func() core.Object {
+		cwd, err := fsys.Getwd()
+		if err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, cwd)
+	})
+
+	obj.Set("div", func(args core.Object) interface{} {
+		if args.Class != "String" {
+			return nil
+		}
+		return *newFile(fsys, args.Self.(string))
+	})
+
+	obj.Set("tempFile", func() core.Object {
+		name, err := fsys.CreateTemp()
+		if err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, name)
+	})
+
+	obj.Set("tempDirectory", func() core.Object {
+		dir, err := fsys.MkdirTemp()
+		if err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, dir)
+	})
+
+	obj.Set("glob", func(args core.Object) interface{} {
+		if args.Class != "String" {
+			return nil
+		}
+		return globFiles(fsys, args.Self.(string))
+	})
+
+	addWatchProtocol(obj, fsys)
+
 	return obj
 }
@@ -1,172 +1,488 @@
 package classes
 
 import (
-	"bufio"
-	"io"
+	"fmt"
 	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"minitalk/types"
 	"minitalk/types/core"
+	"minitalk/types/errors"
 )
 
+type filePath string
+
+func (f filePath) String() string {
+	return "a File(" + types.NewStringObject(string(f)).PrintString() + ")"
+}
+
 func NewFileClass(path string) *core.Object {
-	p := filepath.Clean(path)
-	abs, _ := filepath.Abs(p)
-	info, err := os.Stat(p)
-	exists := err == nil
+	return newFile(osFileSystem{}, path)
+}
+
+func newFile(fsys fileSystem, path string) *core.Object {
+	paths := fsys.Paths()
+	p := paths.clean(path)
+	abs, _ := fsys.Abs(p)
 
-	obj := core.NewObject(p, "File")
+	obj := core.NewObject(filePath(p), "File")
+
+	stat := func() (os.FileInfo, bool) {
+		info, err := fsys.Stat(p)
+		return info, err == nil
+	}
+	flag := func(test func(info os.FileInfo) bool) func() core.Object {
+		return func() core.Object {
+			info, ok := stat()
+			return types.NewBoolObject(ok && test(info)).Object
+		}
+	}
+	timestamp := func(get func(info os.FileInfo) (time.Time, bool)) func() core.Object {
+		return func() core.Object {
+			info, ok := stat()
+			if !ok {
+				return *core.NewObject(nil, "Nil")
+			}
+			t, ok := get(info)
+			if !ok {
+				return *core.NewObject(nil, "Nil")
+			}
+			return types.NewDateAndTimeObject(t).Object
+		}
+	}
 
-	obj.Set("basename", types.NewStringObject(filepath.Base(p)).Object)
-	obj.Set("baseNameWithoutExtension", types.NewStringObject(strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))).Object)
-	obj.Set("extension", types.NewStringObject(filepath.Ext(p)).Object)
+	obj.Set("basename", types.NewStringObject(paths.base(p)).Object)
+	obj.Set("baseNameWithoutExtension", types.NewStringObject(strings.TrimSuffix(paths.base(p), paths.ext(p))).Object)
+	obj.Set("extension", types.NewStringObject(paths.ext(p)).Object)
 	obj.Set("fullName", types.NewStringObject(abs).Object)
 	obj.Set("path", types.NewStringObject(p).Object)
-	obj.Set("parent", types.NewStringObject(filepath.Dir(p)).Object)
-	if exists {
-		obj.Set("size", types.NewIntegerObject(info.Size()).Object)
-		obj.Set("modificationTime", types.NewStringObject(info.ModTime().Format(time.RFC3339)).Object)
-	} else {
-		obj.Set("size", types.NewIntegerObject(0).Object)
-		obj.Set("modificationTime", types.NewStringObject("").Object)
-	}
-	obj.Set("exists", types.NewBoolObject(exists).Object)
-	obj.Set("isAbsent", types.NewBoolObject(!exists).Object)
-	obj.Set("isFile", types.NewBoolObject(exists && info.Mode().IsRegular()).Object)
-	obj.Set("isDirectory", types.NewBoolObject(exists && info.IsDir()).Object)
-	obj.Set("isSymlink", types.NewBoolObject(exists && isSymlink(p)).Object)
-	obj.Set("isEmpty", types.NewBoolObject(isEmptyPath(p)).Object)
-	obj.Set("isReadable", types.NewBoolObject(isReadable(p)).Object)
-	obj.Set("isWritable", types.NewBoolObject(isWritable(p)).Object)
-	obj.Set("isExecutable", types.NewBoolObject(exists && info.Mode()&0111 != 0).Object)
-	obj.Set("isHidden", types.NewBoolObject(strings.HasPrefix(filepath.Base(p), ".")).Object)
+	obj.Set("parent", func() core.Object { return *newFile(fsys, paths.dir(p)) })
+	obj.Set("size", func() core.Object {
+		info, ok := stat()
+		if !ok {
+			return types.NewIntegerObject(0).Object
+		}
+		return types.NewIntegerObject(info.Size()).Object
+	})
+	obj.Set("modificationTime", timestamp(func(info os.FileInfo) (time.Time, bool) { return info.ModTime(), true }))
+	obj.Set("accessTime", timestamp(accessTime))
+	obj.Set("creationTime", timestamp(func(info os.FileInfo) (time.Time, bool) { return creationTime(p, info) }))
+	obj.Set("exists", flag(func(info os.FileInfo) bool { return true }))
+	obj.Set("isAbsent", func() core.Object {
+		_, ok := stat()
+		return types.NewBoolObject(!ok).Object
+	})
+	obj.Set("isFile", flag(func(info os.FileInfo) bool { return info.Mode().IsRegular() }))
+	obj.Set("isDirectory", flag(func(info os.FileInfo) bool { return info.IsDir() }))
+	obj.Set("isSymlink", func() core.Object { return types.NewBoolObject(isSymlink(fsys, p)).Object })
+	obj.Set("isEmpty", func() core.Object { return types.NewBoolObject(isEmptyPath(fsys, p)).Object })
+	obj.Set("isReadable", func() core.Object { return types.NewBoolObject(fsys.Access(p, false)).Object })
+	obj.Set("isWritable", func() core.Object { return types.NewBoolObject(fsys.Access(p, true)).Object })
+	obj.Set("isExecutable", flag(func(info os.FileInfo) bool { return info.Mode()&0111 != 0 }))
+	obj.Set("isHidden", types.NewBoolObject(strings.HasPrefix(paths.base(p), ".")).Object)
+	obj.Set("permissions", func() core.Object {
+		info, err := fsys.Stat(p)
+		if err != nil {
+			return fileSystemError(err)
+		}
+		return types.NewStringObject(info.Mode().Perm().String()[1:]).Object
+	})
+	obj.Set("chmod", func(arg core.Object) interface{} {
+		var mode int64
+		switch arg.Class {
+		case "Integer":
+			mode = arg.Self.(int64)
+		case "String":
+			parsed, err := strconv.ParseInt(arg.Self.(string), 8, 64)
+			if err != nil {
+				return errors.NewValueError(fmt.Sprintf("Invalid permissions %s", arg.Self.(string))).Object
+			}
+			mode = parsed
+		default:
+			return nil
+		}
+		if mode < 0 || mode > 0777 {
+			return errors.NewValueError(fmt.Sprintf("Invalid permissions %s", arg.DisplayString())).Object
+		}
+		if err := fsys.Chmod(p, os.FileMode(mode)); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, p)
+	})
+	obj.Set("owner", func() core.Object {
+		info, err := fsys.Stat(p)
+		if err != nil {
+			return fileSystemError(err)
+		}
+		name, ok := fileOwner(info)
+		if !ok {
+			return *core.NewObject(nil, "Nil")
+		}
+		return types.NewStringObject(name).Object
+	})
+	obj.Set("touch", func() core.Object {
+		f, err := fsys.OpenFile(p, os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return fileSystemError(err)
+		}
+		f.Close()
+		now := time.Now()
+		if err := fsys.Chtimes(p, now, now); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, p)
+	})
+	obj.Set("resolveSymlink", func() core.Object {
+		target, err := fsys.EvalSymlinks(p)
+		if err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, target)
+	})
 	obj.Set("contents", func() core.Object {
-		data, err := os.ReadFile(p)
+		data, err := fsys.ReadFile(p)
 		if err != nil {
-			return types.NewStringObject("").Object
+			return fileSystemError(err)
 		}
 		return types.NewStringObject(string(data)).Object
 	})
-	obj.Set("tell", types.NewIntegerObject(0).Object)
-	obj.Set("seek", func(arg core.Object) interface{} {
-		if arg.Class != "Integer" {
+	obj.Set("div", func(arg core.Object) interface{} {
+		if arg.Class != "String" {
 			return nil
 		}
-		obj.Set("tell", arg)
+		return *newFile(fsys, paths.join(p, arg.Self.(string)))
+	})
+	obj.Set("createDirectory", func() core.Object {
+		if err := fsys.Mkdir(p, 0755); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, p)
+	})
+	obj.Set("ensureCreateDirectory", func() core.Object {
+		if err := fsys.MkdirAll(p, 0755); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, p)
+	})
+	obj.Set("delete", func() core.Object {
+		if err := fsys.Remove(p); err != nil {
+			return fileSystemError(err)
+		}
+		return types.NewBoolObject(true).Object
+	})
+	obj.Set("deleteAll", func() core.Object {
+		if err := fsys.RemoveAll(p); err != nil {
+			return fileSystemError(err)
+		}
 		return types.NewBoolObject(true).Object
 	})
-	obj.Set("nextLine", func() core.Object {
-		f, err := os.Open(p)
+	obj.Set("renameTo", func(arg core.Object) interface{} {
+		if arg.Class != "String" {
+			return nil
+		}
+		target := paths.join(paths.dir(p), arg.Self.(string))
+		if err := fsys.Rename(p, target); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, target)
+	})
+	obj.Set("moveTo", func(arg core.Object) interface{} {
+		target, ok := targetPath(fsys, arg, p)
+		if !ok {
+			return nil
+		}
+		if err := fsys.Rename(p, target); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, target)
+	})
+	obj.Set("copyTo", func(arg core.Object) interface{} {
+		target, ok := targetPath(fsys, arg, p)
+		if !ok {
+			return nil
+		}
+		if contains(fsys, p, target) {
+			return errors.NewFileSystemError(fmt.Sprintf("Cannot copy %s into %s, which is inside it", p, target)).Object
+		}
+		if err := copyPath(fsys, p, target); err != nil {
+			return fileSystemError(err)
+		}
+		return *newFile(fsys, target)
+	})
+	obj.Set("children", func() core.Object {
+		entries, err := fsys.ReadDir(p)
 		if err != nil {
-			return types.NewStringObject("").Object
+			return fileSystemError(err)
 		}
-		defer f.Close()
-
-		var pos int64
-		tell, ok := obj.Get("tell")
-		if ok {
-			if tellObj, ok := tell.(core.Object); ok {
-				pos = tellObj.Self.(int64)
+		arr := []*core.Object{}
+		for _, e := range entries {
+			arr = append(arr, newFile(fsys, paths.join(p, e.Name())))
+		}
+		return types.NewArrayObject(arr).Object
+	})
+	obj.Set("allChildren", func() core.Object {
+		arr := []*core.Object{}
+		err := walk(fsys, p, func(path string, info os.FileInfo) error {
+			if path != p {
+				arr = append(arr, newFile(fsys, path))
 			}
+			return nil
+		})
+		if err != nil {
+			return fileSystemError(err)
 		}
-
-		_, err = f.Seek(pos, io.SeekStart)
+		return types.NewArrayObject(arr).Object
+	})
+	obj.Set("glob", func(arg core.Object) interface{} {
+		if arg.Class != "String" {
+			return nil
+		}
+		return globFiles(fsys, paths.join(p, arg.Self.(string)))
+	})
+	var offset int64
+	reader := types.AddReadProtocol(obj, func() (string, bool) {
+		f, err := fsys.OpenFile(p, os.O_RDONLY, 0)
 		if err != nil {
-			return types.NewStringObject("").Object
+			return "", false
 		}
+		defer f.Close()
 
-		reader := bufio.NewReader(f)
-		line, err := reader.ReadString('\n')
-		if err != nil && err != io.EOF {
-			return types.NewStringObject("").Object
+		buf := make([]byte, 4096)
+		n, _ := f.ReadAt(buf, offset)
+		n = completeRunes(buf[:n])
+		if n == 0 {
+			return "", false
 		}
-
-		pos += int64(len(line))
-		obj.Set("tell", types.NewIntegerObject(pos).Object)
-
-		return types.NewStringObject(strings.TrimRight(line, "\r\n")).Object
+		offset += int64(n)
+		return string(buf[:n]), true
 	})
-	obj.Set("write", func(arg core.Object) interface{} {
-		if arg.Class != "String" {
+	types.AddWriteProtocol(obj, func(s string) {
+		f, err := fsys.OpenFile(p, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		f.Write([]byte(s))
+	})
+	obj.Set("tell", func() core.Object {
+		return types.NewIntegerObject(offset - int64(reader.Buffered())).Object
+	})
+	obj.Set("seek", func(arg core.Object) interface{} {
+		if arg.Class != "Integer" {
 			return nil
 		}
-		content := arg.Self.(string)
-		err := os.WriteFile(p, []byte(content), 0644)
+		offset = arg.Self.(int64)
+		reader.Discard()
+		return types.NewBoolObject(true).Object
+	})
+	store := func(data []byte, flag int) core.Object {
+		f, err := fsys.OpenFile(p, flag|os.O_WRONLY|os.O_CREATE, 0644)
 		if err != nil {
-			return types.NewBoolObject(false).Object
+			return fileSystemError(err)
 		}
-		info, err := os.Stat(p)
-		if err == nil {
-			obj.Set("size", types.NewIntegerObject(info.Size()).Object)
-			obj.Set("modificationTime", types.NewStringObject(info.ModTime().Format(time.RFC3339)).Object)
+		defer f.Close()
+
+		_, err = f.Write(data)
+		if err != nil {
+			return fileSystemError(err)
 		}
 		return types.NewBoolObject(true).Object
+	}
+	obj.Set("write", func(arg core.Object) interface{} {
+		if arg.Class != "String" {
+			return nil
+		}
+		return store([]byte(arg.Self.(string)), os.O_TRUNC)
 	})
-
 	obj.Set("append", func(arg core.Object) interface{} {
 		if arg.Class != "String" {
 			return nil
 		}
-		content := arg.Self.(string)
-		f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
-		if err != nil {
-			return types.NewBoolObject(false).Object
+		return store([]byte(arg.Self.(string)), os.O_APPEND)
+	})
+	obj.Set("writeBytes", func(arg core.Object) interface{} {
+		if arg.Class != "ByteArray" {
+			return nil
 		}
-		defer f.Close()
-
-		_, err = f.WriteString(content)
+		return store(arg.Self.([]byte), os.O_TRUNC)
+	})
+	obj.Set("appendBytes", func(arg core.Object) interface{} {
+		if arg.Class != "ByteArray" {
+			return nil
+		}
+		return store(arg.Self.([]byte), os.O_APPEND)
+	})
+	obj.Set("readStream", func() core.Object { return openFileHandle(fsys, p, "read") })
+	obj.Set("writeStream", func() core.Object { return openFileHandle(fsys, p, "write") })
+	obj.Set("appendStream", func() core.Object { return openFileHandle(fsys, p, "append") })
+	obj.Set("readStreamDo", func(arg core.Object) interface{} {
+		if arg.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := arg.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		if noArgs, ok := noArgsVal.(int64); !ok || noArgs != 1 {
+			return errors.NewValueError("CodeBlock must have 1 argument").Object
+		}
+		valFn, ok := arg.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFn.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+		stream := openFileHandle(fsys, p, "read")
+		if stream.Class != "FileStream" {
+			return stream
+		}
+		defer stream.Self.(*fileHandle).close()
+		return callable(stream)
+	})
+	obj.Set("binaryContents", func() core.Object {
+		data, err := fsys.ReadFile(p)
 		if err != nil {
-			return types.NewBoolObject(false).Object
+			return fileSystemError(err)
 		}
-
-		info, err := os.Stat(p)
-		if err == nil {
-			obj.Set("size", types.NewIntegerObject(info.Size()).Object)
-			obj.Set("modificationTime", types.NewStringObject(info.ModTime().Format(time.RFC3339)).Object)
+		return types.NewByteArrayObject(data).Object
+	})
+	obj.Set("binaryReadStream", func() core.Object {
+		f, err := fsys.OpenFile(p, os.O_RDONLY, 0)
+		if err != nil {
+			return fileSystemError(err)
 		}
-
-		return types.NewBoolObject(true).Object
+		h := &fileHandle{path: p, mode: "binary read", file: f}
+		openHandles[h] = struct{}{}
+		buf := make([]byte, 4096)
+		stream := types.NewBinaryReadStream(func() (string, bool) {
+			if h.closed {
+				return "", false
+			}
+			n, err := f.Read(buf)
+			if n == 0 && err != nil {
+				h.close()
+				return "", false
+			}
+			return string(buf[:n]), true
+		})
+		stream.Set("isClosed", func() core.Object { return types.NewBoolObject(h.closed).Object })
+		stream.Set("close", func() core.Object {
+			if err := h.close(); err != nil {
+				return fileSystemError(err)
+			}
+			return types.NewBoolObject(true).Object
+		})
+		return stream.Object
 	})
 
 	return obj
 }
 
-func isSymlink(path string) bool {
-	info, err := os.Lstat(path)
-	return err == nil && info.Mode()&os.ModeSymlink != 0
+func fileSystemError(err error) core.Object {
+	return errors.NewFileSystemError(err.Error()).Object
 }
 
-func isEmptyPath(path string) bool {
-	info, err := os.Stat(path)
-	if err != nil {
-		return false
+func targetPath(fsys fileSystem, arg core.Object, source string) (string, bool) {
+	paths := fsys.Paths()
+	var target string
+	switch arg.Class {
+	case "String":
+		target = paths.clean(arg.Self.(string))
+	case "File":
+		target = string(arg.Self.(filePath))
+	default:
+		return "", false
 	}
-	if info.IsDir() {
-		f, _ := os.Open(path)
-		names, _ := f.Readdirnames(1)
-		f.Close()
-		return len(names) == 0
+	if info, err := fsys.Stat(target); err == nil && info.IsDir() {
+		target = paths.join(target, paths.base(source))
 	}
-	return info.Size() == 0
+	return target, true
 }
 
-func isReadable(path string) bool {
-	f, err := os.Open(path)
+// contains reports whether path is dir or lies below it.
+func contains(fsys fileSystem, dir, path string) bool {
+	paths := fsys.Paths()
+	if abs, err := fsys.Abs(dir); err == nil {
+		dir = abs
+	}
+	if abs, err := fsys.Abs(path); err == nil {
+		path = abs
+	}
+	for {
+		if path == dir {
+			return true
+		}
+		parent := paths.dir(path)
+		if parent == path {
+			return false
+		}
+		path = parent
+	}
+}
+
+func copyPath(fsys fileSystem, src, dst string) error {
+	return walk(fsys, src, func(path string, info os.FileInfo) error {
+		target := dst + strings.TrimPrefix(path, src)
+		if info.IsDir() {
+			return fsys.MkdirAll(target, 0755)
+		}
+		data, err := fsys.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := fsys.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.Write(data)
+		return err
+	})
+}
+
+func globFiles(fsys fileSystem, pattern string) core.Object {
+	matches, err := fsys.Glob(pattern)
 	if err != nil {
-		return false
+		return errors.NewFileSystemError(fmt.Sprintf("Invalid glob pattern %s", pattern)).Object
+	}
+	arr := []*core.Object{}
+	for _, m := range matches {
+		arr = append(arr, newFile(fsys, m))
 	}
-	f.Close()
-	return true
+	return types.NewArrayObject(arr).Object
 }
 
-func isWritable(path string) bool {
-	f, err := os.OpenFile(path, os.O_WRONLY, 0666)
+func completeRunes(data []byte) int {
+	n := len(data)
+	for i := 1; i <= utf8.UTFMax && i <= n; i++ {
+		if utf8.RuneStart(data[n-i]) {
+			if !utf8.FullRune(data[n-i:]) {
+				return n - i
+			}
+			break
+		}
+	}
+	return n
+}
+
+func isSymlink(fsys fileSystem, path string) bool {
+	info, err := fsys.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
+
+func isEmptyPath(fsys fileSystem, path string) bool {
+	info, err := fsys.Stat(path)
 	if err != nil {
 		return false
 	}
-	f.Close()
-	return true
+	if info.IsDir() {
+		entries, _ := fsys.ReadDir(path)
+		return len(entries) == 0
+	}
+	return info.Size() == 0
 }
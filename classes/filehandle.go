@@ -0,0 +1,226 @@
+package classes
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+
+	"minitalk/types"
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+type fileHandle struct {
+	path   string
+	mode   string
+	file   vfsFile
+	writer *bufio.Writer
+	err    error
+	closed bool
+}
+
+func (h *fileHandle) String() string {
+	return "a FileStream(" + types.NewStringObject(h.path).PrintString() + ")"
+}
+
+func (h *fileHandle) close() error {
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	delete(openHandles, h)
+	var err error
+	if h.writer != nil {
+		err = h.writer.Flush()
+	}
+	if cerr := h.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+var openHandles = make(map[*fileHandle]struct{})
+
+func CloseOpenHandles() {
+	leaked := make([]*fileHandle, 0, len(openHandles))
+	for h := range openHandles {
+		leaked = append(leaked, h)
+	}
+	sort.Slice(leaked, func(i, j int) bool { return leaked[i].path < leaked[j].path })
+	for _, h := range leaked {
+		fmt.Fprintf(os.Stderr, "Warning: %s stream on %s was never closed\n", h.mode, h.path)
+		h.close()
+	}
+}
+
+func openFileHandle(fsys fileSystem, path, mode string) core.Object {
+	flags := os.O_RDONLY
+	switch mode {
+	case "write":
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	case "append":
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := fsys.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fileSystemError(err)
+	}
+	h := &fileHandle{path: path, mode: mode, file: f}
+	openHandles[h] = struct{}{}
+
+	obj := core.NewObject(h, "FileStream")
+	if mode == "read" {
+		reader := bufio.NewReader(f)
+		var pending []byte
+		types.AddReadProtocol(obj, func() (string, bool) {
+			if h.closed {
+				return "", false
+			}
+			buf := make([]byte, 4096)
+			n, _ := reader.Read(buf)
+			if n == 0 {
+				if len(pending) > 0 {
+					chunk := string(pending)
+					pending = nil
+					return chunk, true
+				}
+				return "", false
+			}
+			data := append(pending, buf[:n]...)
+			complete := completeRunes(data)
+			pending = append([]byte{}, data[complete:]...)
+			return string(data[:complete]), true
+		})
+		obj.Set("lines", func() core.Object { return newLineIterator(obj) })
+	} else {
+		h.writer = bufio.NewWriter(f)
+		types.AddWriteProtocol(obj, func(s string) {
+			if _, err := h.writer.WriteString(s); err != nil {
+				h.err = err
+			}
+		})
+		for _, selector := range []string{"nextPutAll", "nextPut", "print", "nl", "tab", "space"} {
+			guardClosed(obj, h, selector)
+		}
+		obj.Set("flush", func() core.Object {
+			if h.closed {
+				return closedError(h)
+			}
+			if err := h.writer.Flush(); err != nil {
+				return fileSystemError(err)
+			}
+			return types.NewBoolObject(true).Object
+		})
+	}
+	obj.Set("isClosed", func() core.Object { return types.NewBoolObject(h.closed).Object })
+	obj.Set("close", func() core.Object {
+		if err := h.close(); err != nil {
+			return fileSystemError(err)
+		}
+		return types.NewBoolObject(true).Object
+	})
+
+	return *obj
+}
+
+func closedError(h *fileHandle) core.Object {
+	return errors.NewFileSystemError(fmt.Sprintf("%s stream on %s is closed", h.mode, h.path)).Object
+}
+
+// guardClosed makes selector fail on a closed stream, and report the error of
+// a write that the buffer could not pass on to the file.
+func guardClosed(obj *core.Object, h *fileHandle, selector string) {
+	method, ok := obj.Get(selector)
+	if !ok {
+		return
+	}
+	failed := func() (core.Object, bool) {
+		if h.err == nil {
+			return core.Object{}, false
+		}
+		err := h.err
+		h.err = nil
+		return fileSystemError(err), true
+	}
+	switch fn := method.(type) {
+	case func() core.Object:
+		obj.Set(selector, func() core.Object {
+			if h.closed {
+				return closedError(h)
+			}
+			result := fn()
+			if err, ok := failed(); ok {
+				return err
+			}
+			return result
+		})
+	case func(core.Object) interface{}:
+		obj.Set(selector, func(arg core.Object) interface{} {
+			if h.closed {
+				return closedError(h)
+			}
+			result := fn(arg)
+			if err, ok := failed(); ok {
+				return err
+			}
+			return result
+		})
+	}
+}
+
+func newLineIterator(stream *core.Object) core.Object {
+	obj := core.NewObject("", "Lines")
+
+	nextLine := func() (core.Object, bool) {
+		atEnd, _ := stream.Get("atEnd")
+		if atEnd.(func() core.Object)().Self == true {
+			return core.Object{}, false
+		}
+		line, _ := stream.Get("nextLine")
+		return line.(func() core.Object)(), true
+	}
+	obj.Set("do", func(arg core.Object) interface{} {
+		if arg.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := arg.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		if noArgs, ok := noArgsVal.(int64); !ok || noArgs != 1 {
+			return errors.NewValueError("CodeBlock must have 1 argument").Object
+		}
+		valFn, ok := arg.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFn.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+		for {
+			line, ok := nextLine()
+			if !ok {
+				break
+			}
+			callable(line)
+		}
+		returnObj := types.NewBoolObject(true).Object
+		returnObj.Set("!printable", false)
+		return returnObj
+	})
+	obj.Set("toArray", func() core.Object {
+		arr := []*core.Object{}
+		for {
+			line, ok := nextLine()
+			if !ok {
+				break
+			}
+			arr = append(arr, &line)
+		}
+		return types.NewArrayObject(arr).Object
+	})
+
+	return *obj
+}
@@ -0,0 +1,31 @@
+package classes
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"minitalk/types"
+	"minitalk/types/core"
+)
+
+func TestWriteErrorReportedByWrite(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("no /dev/full")
+	}
+	stream := openFileHandle(osFileSystem{}, "/dev/full", "write")
+	if stream.Class != "FileStream" {
+		t.Fatalf("Expected a FileStream but got %s", stream.String())
+	}
+	defer stream.Self.(*fileHandle).close()
+
+	putAll, _ := stream.Get("nextPutAll")
+	chunk := types.NewStringObject(strings.Repeat("x", 1024)).Object
+	for i := 0; i < 8; i++ {
+		result, _ := putAll.(func(core.Object) interface{})(chunk).(core.Object)
+		if result.Class == "FileSystemError" {
+			return
+		}
+	}
+	t.Errorf("Expected nextPutAll: to answer a FileSystemError once the buffer is written")
+}
@@ -0,0 +1,25 @@
+//go:build !linux && !darwin && !windows
+
+package classes
+
+import (
+	"os"
+	"time"
+)
+
+func accessTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func creationTime(path string, info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func fileOwner(info os.FileInfo) (string, bool) {
+	return "", false
+}
+
+func canAccess(path string, write bool) bool {
+	info, err := os.Stat(path)
+	return err == nil && permitted(info, write)
+}
@@ -0,0 +1,32 @@
+package classes
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+func accessTime(info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, data.LastAccessTime.Nanoseconds()), true
+}
+
+func creationTime(path string, info os.FileInfo) (time.Time, bool) {
+	data, ok := info.Sys().(*syscall.Win32FileAttributeData)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(0, data.CreationTime.Nanoseconds()), true
+}
+
+func fileOwner(info os.FileInfo) (string, bool) {
+	return "", false
+}
+
+func canAccess(path string, write bool) bool {
+	info, err := os.Stat(path)
+	return err == nil && permitted(info, write)
+}
@@ -1,13 +1,43 @@
 package classes
 
 import (
+	"os"
+
 	"minitalk/types/core"
+	"minitalk/types/errors"
 )
 
 func NewFileSystemClass() *core.Object {
 	obj := core.NewObject("", "FileSystem")
 
 	obj.Set("disk", *NewDiskClass())
+	obj.Set("memory", func() core.Object { return *NewMemoryDiskClass() })
+	obj.Set("withTempDirectoryDo", func(arg core.Object) interface{} {
+		if arg.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := arg.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		if noArgs, ok := noArgsVal.(int64); !ok || noArgs != 1 {
+			return errors.NewValueError("CodeBlock must have 1 argument").Object
+		}
+		valFn, ok := arg.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFn.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+		dir, err := os.MkdirTemp("", "minitalk-*")
+		if err != nil {
+			return fileSystemError(err)
+		}
+		defer os.RemoveAll(dir)
+		return callable(*NewFileClass(dir))
+	})
 
 	return obj
 }
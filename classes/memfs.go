@@ -0,0 +1,343 @@
+package classes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	errIsDir    = errors.New("is a directory")
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+type memNode struct {
+	data    []byte
+	dir     bool
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+type memInfo struct {
+	name string
+	node *memNode
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return int64(len(i.node.data)) }
+func (i memInfo) ModTime() time.Time { return i.node.modTime }
+func (i memInfo) IsDir() bool        { return i.node.dir }
+func (i memInfo) Sys() interface{}   { return nil }
+func (i memInfo) Mode() fs.FileMode {
+	if i.node.dir {
+		return fs.ModeDir | i.node.mode
+	}
+	return i.node.mode
+}
+
+type memFile struct {
+	node   *memNode
+	pos    int64
+	append bool
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	n, err := f.ReadAt(p, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.append {
+		f.pos = int64(len(f.node.data))
+	}
+	if end := f.pos + int64(len(p)); end > int64(len(f.node.data)) {
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	copy(f.node.data[f.pos:], p)
+	f.pos += int64(len(p))
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Close() error { return nil }
+
+type memFileSystem struct {
+	nodes map[string]*memNode
+	temp  int
+}
+
+func newMemFileSystem() *memFileSystem {
+	now := time.Now()
+	return &memFileSystem{nodes: map[string]*memNode{
+		"/":    {dir: true, mode: 0755, modTime: now},
+		"/tmp": {dir: true, mode: 0777, modTime: now},
+	}}
+}
+
+func (m *memFileSystem) resolve(name string) string {
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return path.Clean(name)
+}
+
+func (m *memFileSystem) lookup(op, name string) (string, *memNode, error) {
+	p := m.resolve(name)
+	node, ok := m.nodes[p]
+	if !ok {
+		return p, nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return p, node, nil
+}
+
+func (m *memFileSystem) checkParent(op, name, p string) error {
+	parent, ok := m.nodes[path.Dir(p)]
+	if !ok {
+		return &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if !parent.dir {
+		return &fs.PathError{Op: op, Path: name, Err: errNotDir}
+	}
+	return nil
+}
+
+func (m *memFileSystem) subtree(p string) []string {
+	keys := []string{}
+	for k := range m.nodes {
+		if k == p || strings.HasPrefix(k, strings.TrimSuffix(p, "/")+"/") {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func (m *memFileSystem) Stat(name string) (fs.FileInfo, error) {
+	p, node, err := m.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return memInfo{path.Base(p), node}, nil
+}
+
+func (m *memFileSystem) Lstat(name string) (fs.FileInfo, error) {
+	return m.Stat(name)
+}
+
+func (m *memFileSystem) ReadFile(name string) ([]byte, error) {
+	_, node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if node.dir {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errIsDir}
+	}
+	return append([]byte{}, node.data...), nil
+}
+
+func (m *memFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (vfsFile, error) {
+	p, node, err := m.lookup("open", name)
+	writing := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if err != nil {
+		if flag&os.O_CREATE == 0 {
+			return nil, err
+		}
+		if err := m.checkParent("open", name, p); err != nil {
+			return nil, err
+		}
+		node = &memNode{mode: perm.Perm(), modTime: time.Now()}
+		m.nodes[p] = node
+	} else if node.dir && writing {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errIsDir}
+	}
+	if flag&os.O_TRUNC != 0 && writing {
+		node.data = nil
+		node.modTime = time.Now()
+	}
+	return &memFile{node: node, append: flag&os.O_APPEND != 0}, nil
+}
+
+func (m *memFileSystem) Mkdir(name string, perm fs.FileMode) error {
+	p := m.resolve(name)
+	if _, ok := m.nodes[p]; ok {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+	if err := m.checkParent("mkdir", name, p); err != nil {
+		return err
+	}
+	m.nodes[p] = &memNode{dir: true, mode: perm.Perm(), modTime: time.Now()}
+	return nil
+}
+
+func (m *memFileSystem) MkdirAll(name string, perm fs.FileMode) error {
+	p := m.resolve(name)
+	if node, ok := m.nodes[p]; ok {
+		if !node.dir {
+			return &fs.PathError{Op: "mkdir", Path: name, Err: errNotDir}
+		}
+		return nil
+	}
+	if err := m.MkdirAll(path.Dir(p), perm); err != nil {
+		return err
+	}
+	return m.Mkdir(p, perm)
+}
+
+func (m *memFileSystem) Remove(name string) error {
+	p, node, err := m.lookup("remove", name)
+	if err != nil {
+		return err
+	}
+	if node.dir && len(m.subtree(p)) > 1 {
+		return &fs.PathError{Op: "remove", Path: name, Err: errNotEmpty}
+	}
+	delete(m.nodes, p)
+	return nil
+}
+
+func (m *memFileSystem) RemoveAll(name string) error {
+	for _, k := range m.subtree(m.resolve(name)) {
+		delete(m.nodes, k)
+	}
+	return nil
+}
+
+func (m *memFileSystem) Rename(oldName, newName string) error {
+	oldPath, _, err := m.lookup("rename", oldName)
+	if err != nil {
+		return err
+	}
+	newPath := m.resolve(newName)
+	if err := m.checkParent("rename", newName, newPath); err != nil {
+		return err
+	}
+	if strings.HasPrefix(newPath, oldPath+"/") {
+		return &fs.PathError{Op: "rename", Path: newName, Err: fs.ErrInvalid}
+	}
+	if target, ok := m.nodes[newPath]; ok && target.dir && len(m.subtree(newPath)) > 1 {
+		return &fs.PathError{Op: "rename", Path: newName, Err: errNotEmpty}
+	}
+	moved := map[string]*memNode{}
+	for _, k := range m.subtree(oldPath) {
+		moved[newPath+strings.TrimPrefix(k, oldPath)] = m.nodes[k]
+		delete(m.nodes, k)
+	}
+	for k, node := range moved {
+		m.nodes[k] = node
+	}
+	return nil
+}
+
+func (m *memFileSystem) ReadDir(name string) ([]fs.DirEntry, error) {
+	p, node, err := m.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if !node.dir {
+		return nil, &fs.PathError{Op: "readdirent", Path: name, Err: errNotDir}
+	}
+	entries := []fs.DirEntry{}
+	for k, child := range m.nodes {
+		if k != p && path.Dir(k) == p {
+			entries = append(entries, fs.FileInfoToDirEntry(memInfo{path.Base(k), child}))
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+func (m *memFileSystem) Access(name string, write bool) bool {
+	info, err := m.Stat(name)
+	return err == nil && permitted(info, write)
+}
+
+func (m *memFileSystem) Chmod(name string, mode fs.FileMode) error {
+	_, node, err := m.lookup("chmod", name)
+	if err != nil {
+		return err
+	}
+	node.mode = mode.Perm()
+	return nil
+}
+
+func (m *memFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	_, node, err := m.lookup("chtimes", name)
+	if err != nil {
+		return err
+	}
+	node.modTime = mtime
+	return nil
+}
+
+func (m *memFileSystem) EvalSymlinks(name string) (string, error) {
+	p, _, err := m.lookup("lstat", name)
+	return p, err
+}
+
+func (m *memFileSystem) Abs(name string) (string, error) {
+	return m.resolve(name), nil
+}
+
+func (m *memFileSystem) Paths() pathSyntax {
+	return slashPaths
+}
+
+func (m *memFileSystem) Getwd() (string, error) {
+	return "/", nil
+}
+
+func (m *memFileSystem) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	resolved := m.resolve(pattern)
+	matches := []string{}
+	for k := range m.nodes {
+		if ok, _ := path.Match(resolved, k); ok {
+			if !strings.HasPrefix(pattern, "/") {
+				k = strings.TrimPrefix(k, "/")
+			}
+			matches = append(matches, k)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (m *memFileSystem) nextTemp() string {
+	m.temp++
+	return fmt.Sprintf("/tmp/minitalk-%d", m.temp)
+}
+
+func (m *memFileSystem) CreateTemp() (string, error) {
+	name := m.nextTemp()
+	f, err := m.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return name, nil
+}
+
+func (m *memFileSystem) MkdirTemp() (string, error) {
+	name := m.nextTemp()
+	return name, m.Mkdir(name, 0700)
+}
@@ -0,0 +1,36 @@
+package classes
+
+import (
+	"minitalk/global"
+	"minitalk/types"
+	"minitalk/types/core"
+)
+
+// ExitRequest is raised as a panic by exit: and quit so that pending ensure:
+// blocks run while the stack unwinds; main recovers it and exits with Code.
+type ExitRequest struct {
+	Code int
+}
+
+func NewSmalltalkClass() *core.Object {
+	obj := core.NewObject("", "Smalltalk")
+
+	obj.Set("arguments", func() core.Object {
+		args := make([]*core.Object, len(global.Arguments))
+		for i, arg := range global.Arguments {
+			args[i] = &types.NewStringObject(arg).Object
+		}
+		return types.NewArrayObject(args).Object
+	})
+	obj.Set("version", func() core.Object { return types.NewStringObject(global.Version).Object })
+	obj.Set("exit", func(args core.Object) interface{} {
+		code, ok := args.Self.(int64)
+		if !ok || args.Class != "Integer" {
+			return nil
+		}
+		panic(ExitRequest{int(code)})
+	})
+	obj.Set("quit", func() core.Object { panic(ExitRequest{0}) })
+
+	return obj
+}
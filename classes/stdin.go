@@ -1,20 +1,47 @@
 package classes
 
 import (
+	"bufio"
+	"io"
+	"os"
+
 	"minitalk/global"
 	"minitalk/types"
 	"minitalk/types/core"
 )
 
+func StdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
 func NewStdinClass() *core.Object {
 	obj := core.NewObject("", "Stdin")
 
-	obj.Set("nextLine", func() core.Object {
-		line, err := global.Liner.Prompt("")
-		if err != nil {
-			return types.NewStringObject("").Object
+	var reader *bufio.Reader
+	types.AddReadProtocol(obj, func() (string, bool) {
+		FlushTranscript()
+		if global.ReplReadsStdin || StdinIsTerminal() {
+			line, err := global.Liner.Prompt("")
+			if err != nil {
+				return "", false
+			}
+			return line + "\n", true
 		}
-		return types.NewStringObject(line).Object
+		if reader == nil {
+			reader = bufio.NewReader(os.Stdin)
+		}
+		chunk, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", false
+		}
+		return chunk, chunk != ""
+	})
+	obj.Set("lines", func() core.Object { return newLineIterator(obj) })
+	obj.Set("linesDo", func(args core.Object) interface{} {
+		lines := newLineIterator(obj)
+		do, _ := lines.Get("do")
+		return do.(func(core.Object) interface{})(args)
 	})
 
 	return obj
@@ -1,29 +1,182 @@
 package classes
 
 import (
-	"fmt"
+	"bufio"
+	"os"
 	"strconv"
+	"strings"
 
 	"minitalk/types"
 	"minitalk/types/core"
+	"minitalk/types/errors"
 )
 
+var (
+	transcriptOut = bufio.NewWriter(os.Stdout)
+	consoles      []func()
+)
+
+func FlushTranscript() {
+	transcriptOut.Flush()
+}
+
+func Shutdown() {
+	for _, restore := range consoles {
+		restore()
+	}
+	CloseOpenHandles()
+	FlushTranscript()
+}
+
+func unescape(raw string) string {
+	unescaped, err := strconv.Unquote(`"` + strings.ReplaceAll(raw, "\n", `\n`) + `"`)
+	if err != nil {
+		return raw
+	}
+	return unescaped
+}
+
+func unprintable(obj core.Object) core.Object {
+	obj.Set("!printable", false)
+	return obj
+}
+
 func NewTranscriptClass() *core.Object {
-	obj := core.NewObject("", "Transcript")
+	return newConsole("Transcript", func(s string) { transcriptOut.WriteString(s) })
+}
+
+func NewStderrClass() *core.Object {
+	return newConsole("Stderr", func(s string) {
+		FlushTranscript()
+		os.Stderr.WriteString(s)
+	})
+}
+
+func newConsole(class string, standard func(string)) *core.Object {
+	obj := core.NewObject("", class)
+
+	target := standard
+	var handle *core.Object
+	restore := func() {
+		if handle != nil {
+			if closeFn, ok := handle.Get("close"); ok {
+				closeFn.(func() core.Object)()
+			}
+			handle = nil
+		}
+		target = standard
+	}
+	consoles = append(consoles, restore)
+
+	write := func(s string) int {
+		target(s)
+		return len(s)
+	}
+	display := func(args core.Object) int {
+		if args.Class == "String" {
+			return write(unescape(args.Self.(string)))
+		}
+		return write(args.DisplayString())
+	}
+	done := func() core.Object { return unprintable(types.NewBoolObject(true).Object) }
 
 	obj.Set("show", func(args core.Object) interface{} {
-		if args.Class != "String" {
+		return unprintable(types.NewIntegerObject(int64(display(args))).Object)
+	})
+	obj.Set("showCr", func(args core.Object) interface{} {
+		n := display(args) + write("\n")
+		return unprintable(types.NewIntegerObject(int64(n)).Object)
+	})
+	obj.Set("display", func(args core.Object) interface{} {
+		display(args)
+		return done()
+	})
+	types.AddWriteProtocol(obj, func(s string) { write(s) })
+	obj.Set("nextPutAll", func(args core.Object) interface{} {
+		if args.Class != "String" && args.Class != "Symbol" {
 			return nil
 		}
-		raw := args.Self.(string)
-		unescaped, err := strconv.Unquote(`"` + raw + `"`)
-		if err != nil {
-			unescaped = raw
+		display(args)
+		return done()
+	})
+	obj.Set("cr", func() core.Object {
+		write("\n")
+		return done()
+	})
+	obj.Set("flush", func() core.Object {
+		if handle != nil {
+			if flushFn, ok := handle.Get("flush"); ok {
+				flushFn.(func() core.Object)()
+			}
+		}
+		FlushTranscript()
+		return done()
+	})
+	obj.Set("clear", func() core.Object {
+		if class == "Transcript" {
+			transcriptOut.Reset(os.Stdout)
+			if info, err := os.Stdout.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+				os.Stdout.WriteString("\x1b[H\x1b[2J")
+			}
+		}
+		return done()
+	})
+	obj.Set("redirectTo", func(args core.Object) interface{} {
+		sink := args
+		if args.Class == "File" {
+			openFn, _ := args.Get("writeStream")
+			sink = openFn.(func() core.Object)()
+			if strings.HasSuffix(sink.Class, "Error") {
+				return sink
+			}
+		}
+		putAll, ok := sink.Get("nextPutAll")
+		if !ok || sink.Class == class {
+			return nil
+		}
+		putAllFn, ok := putAll.(func(core.Object) interface{})
+		if !ok {
+			return nil
+		}
+		restore()
+		if args.Class == "File" {
+			handle = &sink
+		}
+		target = func(s string) { putAllFn(types.NewStringObject(s).Object) }
+		return done()
+	})
+	obj.Set("restore", func() core.Object {
+		restore()
+		return done()
+	})
+	obj.Set("captureDuring", func(args core.Object) interface{} {
+		if args.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := args.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		if noArgs, ok := noArgsVal.(int64); !ok || noArgs != 0 {
+			return errors.NewValueError("CodeBlock must have no arguments").Object
+		}
+		valFn, ok := args.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFn.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+		var captured strings.Builder
+		previous := target
+		target = func(s string) { captured.WriteString(s) }
+		defer func() { target = previous }()
+		result := callable()
+		if res, ok := result.(core.Object); ok && strings.HasSuffix(res.Class, "Error") {
+			return res
 		}
-		n, _ := fmt.Print(unescaped)
-		nBytes := types.NewIntegerObject(int64(n)).Object
-		nBytes.Set("!printable", false)
-		return nBytes
+		return types.NewStringObject(captured.String()).Object
 	})
 
 	return obj
@@ -0,0 +1,127 @@
+package classes
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+type vfsFile interface {
+	io.Reader
+	io.Writer
+	io.ReaderAt
+	io.Closer
+}
+
+type fileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	Lstat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	OpenFile(name string, flag int, perm fs.FileMode) (vfsFile, error)
+	Mkdir(name string, perm fs.FileMode) error
+	MkdirAll(name string, perm fs.FileMode) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldName, newName string) error
+	ReadDir(name string) ([]fs.DirEntry, error)
+	Chmod(name string, mode fs.FileMode) error
+	Access(name string, write bool) bool
+	Chtimes(name string, atime, mtime time.Time) error
+	EvalSymlinks(name string) (string, error)
+	Abs(name string) (string, error)
+	Getwd() (string, error)
+	Glob(pattern string) ([]string, error)
+	CreateTemp() (string, error)
+	MkdirTemp() (string, error)
+	Paths() pathSyntax
+}
+
+// pathSyntax holds the path functions a file system understands: the host's
+// for the disk, and slash-separated ones for the in-memory file system.
+type pathSyntax struct {
+	clean func(string) string
+	join  func(...string) string
+	dir   func(string) string
+	base  func(string) string
+	ext   func(string) string
+}
+
+var (
+	hostPaths  = pathSyntax{filepath.Clean, filepath.Join, filepath.Dir, filepath.Base, filepath.Ext}
+	slashPaths = pathSyntax{path.Clean, path.Join, path.Dir, path.Base, path.Ext}
+)
+
+type osFileSystem struct{}
+
+func (osFileSystem) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (osFileSystem) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (osFileSystem) ReadFile(name string) ([]byte, error)   { return os.ReadFile(name) }
+func (osFileSystem) OpenFile(name string, flag int, perm fs.FileMode) (vfsFile, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+func (osFileSystem) Mkdir(name string, perm fs.FileMode) error    { return os.Mkdir(name, perm) }
+func (osFileSystem) MkdirAll(name string, perm fs.FileMode) error { return os.MkdirAll(name, perm) }
+func (osFileSystem) Remove(name string) error                     { return os.Remove(name) }
+func (osFileSystem) RemoveAll(name string) error                  { return os.RemoveAll(name) }
+func (osFileSystem) Rename(oldName, newName string) error         { return os.Rename(oldName, newName) }
+func (osFileSystem) ReadDir(name string) ([]fs.DirEntry, error)   { return os.ReadDir(name) }
+func (osFileSystem) Chmod(name string, mode fs.FileMode) error    { return os.Chmod(name, mode) }
+func (osFileSystem) Access(name string, write bool) bool          { return canAccess(name, write) }
+func (osFileSystem) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(name, atime, mtime)
+}
+func (osFileSystem) EvalSymlinks(name string) (string, error) { return filepath.EvalSymlinks(name) }
+func (osFileSystem) Abs(name string) (string, error)          { return filepath.Abs(name) }
+func (osFileSystem) Getwd() (string, error)                   { return os.Getwd() }
+func (osFileSystem) Glob(pattern string) ([]string, error)    { return filepath.Glob(pattern) }
+func (osFileSystem) CreateTemp() (string, error) {
+	f, err := os.CreateTemp("", "minitalk-*")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	return f.Name(), nil
+}
+func (osFileSystem) MkdirTemp() (string, error) { return os.MkdirTemp("", "minitalk-*") }
+func (osFileSystem) Paths() pathSyntax          { return hostPaths }
+
+// permitted checks the owner bits of a file's mode, for file systems that
+// have no notion of users.
+func permitted(info fs.FileInfo, write bool) bool {
+	if write {
+		return info.Mode().Perm()&0200 != 0
+	}
+	return info.Mode().Perm()&0400 != 0
+}
+
+func walk(fsys fileSystem, root string, fn func(path string, info fs.FileInfo) error) error {
+	info, err := fsys.Lstat(root)
+	if err != nil {
+		return err
+	}
+	if err := fn(root, info); err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return nil
+	}
+	entries, err := fsys.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, e := range entries {
+		if err := walk(fsys, fsys.Paths().join(root, e.Name()), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package classes
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"minitalk/types"
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+const watchInterval = 250 * time.Millisecond
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	dir     bool
+}
+
+type fileEvent struct {
+	kind string
+	path string
+}
+
+func (e *fileEvent) String() string {
+	return "a FileEvent(#" + e.kind + " " + types.NewStringObject(e.path).PrintString() + ")"
+}
+
+func snapshot(fsys fileSystem, root string) map[string]fileState {
+	states := make(map[string]fileState)
+	walk(fsys, root, func(path string, info os.FileInfo) error {
+		states[path] = fileState{info.ModTime(), info.Size(), info.IsDir()}
+		return nil
+	})
+	return states
+}
+
+func diffSnapshots(before, after map[string]fileState) []fileEvent {
+	events := []fileEvent{}
+	for path, state := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			events = append(events, fileEvent{"created", path})
+		case !state.dir && (!state.modTime.Equal(old.modTime) || state.size != old.size):
+			events = append(events, fileEvent{"modified", path})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			events = append(events, fileEvent{"deleted", path})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].path < events[j].path })
+	return events
+}
+
+func newFileEvent(fsys fileSystem, event fileEvent) core.Object {
+	obj := core.NewObject(&event, "FileEvent")
+
+	obj.Set("type", event.kind, types.SymbolConstructor)
+	obj.Set("file", func() core.Object { return *newFile(fsys, event.path) })
+	obj.Set("isCreated", event.kind == "created", types.ObjectConstructor)
+	obj.Set("isModified", event.kind == "modified", types.ObjectConstructor)
+	obj.Set("isDeleted", event.kind == "deleted", types.ObjectConstructor)
+
+	return *obj
+}
+
+// addWatchProtocol adds watch:do:, which polls on the calling goroutine until
+// stopWatching is sent from inside the block.
+func addWatchProtocol(obj *core.Object, fsys fileSystem) {
+	stopped := false
+
+	obj.SetOptional("watch", "do", core.NewObject(nil, ""))
+	obj.Set("watch", func(args core.Object) interface{} {
+		if args.Class != "String" {
+			return nil
+		}
+		block, _ := obj.GetOptional("watch", "do")
+		if block.Class != "CodeBlock" {
+			return errors.NewValueError("watch: expects a CodeBlock for do:").Object
+		}
+		valFn, ok := block.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFn.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+		root := args.Self.(string)
+		if _, err := fsys.Stat(root); err != nil {
+			return fileSystemError(err)
+		}
+
+		stopped = false
+		before := snapshot(fsys, root)
+		for !stopped {
+			time.Sleep(watchInterval)
+			after := snapshot(fsys, root)
+			for _, event := range diffSnapshots(before, after) {
+				callable(newFileEvent(fsys, event))
+				if stopped {
+					break
+				}
+			}
+			FlushTranscript()
+			before = after
+		}
+		return types.NewBoolObject(true).Object
+	})
+	obj.Set("stopWatching", func() core.Object {
+		stopped = true
+		return types.NewBoolObject(true).Object
+	})
+}
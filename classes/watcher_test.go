@@ -0,0 +1,66 @@
+package classes
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"minitalk/types"
+	"minitalk/types/core"
+)
+
+// TestWatchDeliversEvents drives a file through its whole life from inside the
+// watch block, so each step is only taken once the previous event arrived. A
+// missed event would leave watch polling, so the test gives up after a while.
+func TestWatchDeliversEvents(t *testing.T) {
+	dir := t.TempDir()
+	start := filepath.Join(dir, "start")
+	target := filepath.Join(dir, "a.txt")
+	disk := NewDiskClass()
+
+	var events []string
+	block := core.NewObject(nil, "CodeBlock")
+	block.Set("value", func(args ...core.Object) interface{} {
+		event := args[0].Self.(*fileEvent)
+		events = append(events, event.kind+" "+filepath.Base(event.path))
+		switch {
+		case event.path == start:
+			os.WriteFile(target, []byte("a"), 0644)
+		case event.kind == "created":
+			os.WriteFile(target, []byte("ab"), 0644)
+		case event.kind == "modified":
+			os.Remove(target)
+		case event.kind == "deleted":
+			stop, _ := disk.Get("stopWatching")
+			stop.(func() core.Object)()
+		}
+		return core.Object{}
+	})
+	disk.SetOptional("watch", "do", block)
+
+	go func() {
+		time.Sleep(watchInterval / 2)
+		os.WriteFile(start, nil, 0644)
+	}()
+	watch, _ := disk.Get("watch")
+	done := make(chan core.Object, 1)
+	go func() {
+		done <- watch.(func(core.Object) interface{})(types.NewStringObject(dir).Object).(core.Object)
+	}()
+	var result core.Object
+	select {
+	case result = <-done:
+	case <-time.After(20 * watchInterval):
+		t.Fatalf("Expected watch to stop after the deletion, but it is still waiting for an event")
+	}
+
+	if result.Self != true {
+		t.Errorf("Expected watch to answer true but got %v", result.String())
+	}
+	expected := []string{"created start", "created a.txt", "modified a.txt", "deleted a.txt"}
+	if !reflect.DeepEqual(events, expected) {
+		t.Errorf("Expected events %v but got %v", expected, events)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"minitalk/classes"
+	"minitalk/tokens"
+)
+
+const commandHelp = `Commands:
+  :help          show this help
+  :history       list previous inputs and their results
+  :vars          list global variables and their classes
+  :del name      delete a global variable
+  :reset         restore the initial global scope
+  :load file     run a script file in this session
+  :save file     write the statements entered so far to a file
+  :time expr     evaluate expr and report wall time and allocations
+  :tokens expr   show the tokens of expr
+  :ast expr      show how expr is parsed
+  exit           leave the REPL
+`
+
+var commandNames = []string{"ast", "del", "help", "history", "load", "reset", "save", "time", "tokens", "vars"}
+
+func isCommand(line string) bool {
+	line = strings.TrimSpace(line)
+	return len(line) > 1 && line[0] == ':' && unicode.IsLetter(rune(line[1]))
+}
+
+func (r *Repl) RunCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimSpace(line), " ")
+	arg = strings.TrimSpace(arg)
+	needsArg := func(what string) bool {
+		if arg == "" {
+			fmt.Fprintf(os.Stderr, "Usage: %s %s\n", name, what)
+			return false
+		}
+		return true
+	}
+
+	switch name {
+	case ":help":
+		fmt.Print(commandHelp)
+	case ":history":
+		for _, entry := range r.history {
+			fmt.Printf("[%d] %s\n", entry.number, entry.input)
+			if entry.output != "" {
+				fmt.Printf("%s=> %s\n", strings.Repeat(" ", len(fmt.Sprint(entry.number))+1), entry.output)
+			}
+		}
+	case ":vars":
+		names := r.GetNames()
+		sort.Strings(names)
+		for _, n := range names {
+			if strings.HasPrefix(n, ":") {
+				continue
+			}
+			val, _ := r.GetVar(n)
+			fmt.Printf("%s: %s\n", n, val.Class)
+		}
+	case ":del":
+		if !needsArg("name") {
+			return
+		}
+		if _, ok := r.GetVar(arg); !ok {
+			fmt.Fprintf(os.Stderr, "NameError: '%s' is not defined\n", arg)
+			return
+		}
+		r.DeleteVar(arg)
+	case ":reset":
+		r.initScope()
+	case ":load":
+		if !needsArg("file") {
+			return
+		}
+		compileFile(r, arg)
+		classes.FlushTranscript()
+	case ":save":
+		if !needsArg("file") {
+			return
+		}
+		var source strings.Builder
+		for _, entry := range r.history {
+			// A period keeps a line such as "-3" from continuing the one before.
+			statement := strings.TrimSpace(entry.input)
+			if !strings.HasSuffix(statement, ".") {
+				statement += "."
+			}
+			source.WriteString(statement + "\n")
+		}
+		if err := os.WriteFile(arg, []byte(source.String()), 0644); err != nil {
+			fmt.Fprintln(os.Stderr, "Error:", err)
+			return
+		}
+		fmt.Printf("Saved %d statements to %s\n", len(r.history), arg)
+	case ":time":
+		if !needsArg("expr") {
+			return
+		}
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		outputs := r.ProcessLine(filterWhitespace(tokens.Lex(arg)))
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+		classes.FlushTranscript()
+		r.display.print(outputs)
+		fmt.Printf("Time: %s, %d allocations, %d bytes\n",
+			elapsed, after.Mallocs-before.Mallocs, after.TotalAlloc-before.TotalAlloc)
+	case ":tokens":
+		if !needsArg("expr") {
+			return
+		}
+		for _, tok := range tokens.Lex(arg) {
+			if tok.Type != tokens.Whitespace {
+				fmt.Printf("%s %s\n", TokenTypeToString(tok.Type), tok.Value)
+			}
+		}
+	case ":ast":
+		if !needsArg("expr") {
+			return
+		}
+		tree, err := dumpAST(arg)
+		if err != nil {
+			syntaxError(err.Error())
+			return
+		}
+		fmt.Print(tree)
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown command %s (try :help)\n", name)
+	}
+}
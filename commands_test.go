@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"minitalk/tokens"
+)
+
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	f()
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return string(out)
+}
+
+// enter evaluates inputs the way the REPL loop does, numbering each one.
+func enter(r *Repl, inputs ...string) {
+	for _, input := range inputs {
+		r.record(input, r.ProcessLine(filterWhitespace(tokens.Lex(input))))
+	}
+}
+
+func TestResultHistory(t *testing.T) {
+	r := NewRepl()
+	enter(r, "x := 6 * 7", "'no' foo", "x + 1")
+
+	cases := []struct{ input, want string }{
+		{"_1", "42"},
+		{"Out at: 3", "43"},
+		{"_1 + (Out at: 1)", "84"},
+		{"_2", "NameError: '_2' is not defined"},
+		{"Out at: 2", "ValueError: Key 2 not found"},
+	}
+	for _, c := range cases {
+		outputs := r.ProcessLine(filterWhitespace(tokens.Lex(c.input)))
+		if len(outputs) == 0 || outputs[len(outputs)-1].String() != c.want {
+			t.Errorf("%s: expected %s but got %v", c.input, c.want, outputs)
+		}
+	}
+}
+
+func TestHistoryCommand(t *testing.T) {
+	r := NewRepl()
+	enter(r, "x := 6 * 7", "'no' foo", "x + 1", "x\n  - 1")
+
+	want := "[1] x := 6 * 7\n  => 42\n[2] 'no' foo\n[3] x + 1\n  => 43\n[4] x\n  - 1\n  => 41\n"
+	if got := captureStdout(t, func() { r.RunCommand(":history") }); got != want {
+		t.Errorf("Expected\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func TestSaveReloadsToSameState(t *testing.T) {
+	r := NewRepl()
+	enter(r, "x := 10", "-3", "y := x + 1")
+	file := filepath.Join(t.TempDir(), "session.sm")
+	captureStdout(t, func() { r.RunCommand(":save " + file) })
+
+	loaded := NewRepl()
+	captureStdout(t, func() { loaded.RunCommand(":load " + file) })
+	for name, want := range map[string]string{"x": "10", "y": "11"} {
+		if got, _ := loaded.GetVar(name); got.String() != want {
+			t.Errorf("Expected %s to be %s after :load but got %s", name, want, got.String())
+		}
+	}
+}
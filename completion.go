@@ -0,0 +1,118 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"minitalk/tokens"
+	"minitalk/types"
+	"minitalk/types/core"
+)
+
+var internalProperties = map[string]bool{"no_arguments": true, "loc": true}
+
+func isWordRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func startsStatement(t tokens.TokenType) bool {
+	switch t {
+	case tokens.Period, tokens.LParen, tokens.LBracket, tokens.Assignment, tokens.Pipe:
+		return true
+	}
+	return false
+}
+
+func selectorsOf(obj core.Object) []string {
+	selectors := []string{}
+	for _, name := range obj.PropertyNames() {
+		if strings.HasPrefix(name, "!") || internalProperties[name] {
+			continue
+		}
+		value, _ := obj.Get(name)
+		switch value.(type) {
+		case core.Overloaded:
+			selectors = append(selectors, name, name+":")
+		case func(core.Object) interface{}:
+			selectors = append(selectors, name+":")
+		default:
+			selectors = append(selectors, name)
+		}
+	}
+	return selectors
+}
+
+// receiverOf answers the object a selector typed after toks would be sent to,
+// when that is known without running any code: a global variable at the start
+// of a statement, or a literal, which is stood in for by an object of its
+// class so that a malformed one is never evaluated.
+func (r *Repl) receiverOf(toks []tokens.Token) (core.Object, bool) {
+	if len(toks) == 0 {
+		return core.Object{}, false
+	}
+	last := toks[len(toks)-1]
+	if len(toks) > 1 && !startsStatement(toks[len(toks)-2].Type) {
+		return core.Object{}, false
+	}
+	switch last.Type {
+	case tokens.Identifier:
+		return r.GetVar(last.Value)
+	case tokens.Integer, tokens.RadixNumber:
+		return types.NewIntegerObject(0).Object, true
+	case tokens.Float:
+		return types.NewFloatObject(0).Object, true
+	case tokens.String:
+		return types.NewStringObject("").Object, true
+	case tokens.Symbol:
+		return types.NewSymbolObject("x").Object, true
+	case tokens.Character:
+		return types.NewCharacterObject('x').Object, true
+	case tokens.Array:
+		return types.NewArrayObject(nil).Object, true
+	case tokens.ByteArray:
+		return types.NewByteArrayObject(nil).Object, true
+	case tokens.True, tokens.False:
+		return types.NewBoolObject(true).Object, true
+	case tokens.Nil:
+		return *core.NewObject(nil, "Nil"), true
+	}
+	return core.Object{}, false
+}
+
+func (r *Repl) complete(line string, pos int) (head string, completions []string, tail string) {
+	runes := []rune(line)
+	if pos > len(runes) {
+		pos = len(runes)
+	}
+	start := pos
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+	head, prefix, tail := string(runes[:start]), string(runes[start:pos]), string(runes[pos:])
+
+	var candidates []string
+	if strings.TrimSpace(head) == ":" && !strings.Contains(head, " ") {
+		candidates = commandNames
+	} else {
+		toks := filterWhitespace(tokens.Lex(head))
+		if len(toks) == 0 || startsStatement(toks[len(toks)-1].Type) || toks[len(toks)-1].Type == tokens.Colon ||
+			isBinaryOperator(toks[len(toks)-1].Type) {
+			for _, name := range r.GetNames() {
+				if !strings.HasPrefix(name, ":") {
+					candidates = append(candidates, name)
+				}
+			}
+		} else if receiver, ok := r.receiverOf(toks); ok {
+			candidates = selectorsOf(receiver)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			completions = append(completions, candidate)
+		}
+	}
+	sort.Strings(completions)
+	return head, completions, tail
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"minitalk/tokens"
+)
+
+func TestCompleteKeepsLastResult(t *testing.T) {
+	r := NewRepl()
+	r.ProcessLine(filterWhitespace(tokens.Lex("42")))
+
+	_, completions, _ := r.complete("'abc' si", 8)
+	if !slices.Contains(completions, "size") {
+		t.Errorf("Expected size among the completions but got %v", completions)
+	}
+	last, _ := r.GetVar("_")
+	if last.String() != "42" {
+		t.Errorf("Expected _ to stay 42 but got %s", last.String())
+	}
+}
+
+func TestCompleteWithoutLastResult(t *testing.T) {
+	r := NewRepl()
+	r.complete("3 ", 2)
+	if _, ok := r.GetVar("_"); ok {
+		t.Errorf("Expected _ to stay undefined")
+	}
+}
+
+func TestCompleteDoesNotEvaluateLiterals(t *testing.T) {
+	r := NewRepl()
+	before := syntaxErrors
+	_, completions, _ := r.complete("99r1 ", 5)
+	if syntaxErrors != before {
+		t.Errorf("Expected no syntax error from completing after 99r1")
+	}
+	if !slices.Contains(completions, "toString") {
+		t.Errorf("Expected Integer selectors among the completions but got %v", completions)
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"minitalk/tokens"
+	"minitalk/types/core"
+)
+
+const (
+	maxElements  = 100
+	maxDepth     = 8
+	maxNodes     = 10000
+	defaultWidth = 80
+	reset        = "\x1b[0m"
+)
+
+type display struct {
+	color    bool
+	errColor bool
+	pretty   bool
+	width    int
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// newDisplay lays results out for the terminal; colors are off when NO_COLOR
+// is set, the stream is not a terminal or it cannot take escape sequences, and
+// layout is off when stdout is not a terminal.
+func newDisplay() *display {
+	noColor := os.Getenv("NO_COLOR") != ""
+	width := terminalWidth(os.Stdout)
+	if width <= 0 {
+		width = defaultWidth
+	}
+	return &display{
+		color:    isTerminal(os.Stdout) && !noColor && enableEscapes(os.Stdout),
+		errColor: isTerminal(os.Stderr) && !noColor && enableEscapes(os.Stderr),
+		pretty:   isTerminal(os.Stdout),
+		width:    width,
+	}
+}
+
+func tokenColor(toks []tokens.Token, i int) string {
+	switch toks[i].Type {
+	case tokens.String, tokens.Character:
+		return "32"
+	case tokens.Symbol:
+		return "35"
+	case tokens.Integer, tokens.Float, tokens.RadixNumber, tokens.ByteArray:
+		return "36"
+	case tokens.Nil, tokens.True, tokens.False:
+		return "33"
+	case tokens.Comment:
+		return "90"
+	case tokens.Illegal, tokens.Error:
+		return "31"
+	case tokens.Identifier:
+		if i+1 < len(toks) && toks[i+1].Type == tokens.Colon {
+			return "34"
+		}
+		if r, _ := utf8.DecodeRuneInString(toks[i].Value); unicode.IsUpper(r) {
+			return "1"
+		}
+	case tokens.Colon:
+		if i > 0 && toks[i-1].Type == tokens.Identifier {
+			return "34"
+		}
+	}
+	return ""
+}
+
+func highlight(src string) string {
+	toks := tokens.Lex(src)
+	var b strings.Builder
+	for i, tok := range toks {
+		if tok.Type == tokens.Array {
+			b.WriteString("#(" + highlight(tok.Value[2:len(tok.Value)-1]) + ")")
+			continue
+		}
+		color := tokenColor(toks, i)
+		if color == "" {
+			b.WriteString(tok.Value)
+			continue
+		}
+		start := "\x1b[" + color + "m"
+		b.WriteString(start + strings.ReplaceAll(tok.Value, "\n", reset+"\n"+start) + reset)
+	}
+	return b.String()
+}
+
+// echo redraws the input just entered, prompts included, with highlighting.
+func (d *display) echo(prompts []string, input string) {
+	lines := strings.Split(highlight(input), "\n")
+	plain := strings.Split(input, "\n")
+	rows := 0
+	for i, line := range plain {
+		rows += 1 + max(utf8.RuneCountInString(prompts[i]+line)-1, 0)/d.width
+	}
+	fmt.Printf("\x1b[%dA\r\x1b[J", rows)
+	for i, line := range lines {
+		fmt.Println(prompts[i] + line)
+	}
+}
+
+// node is a result as laid out for display: leaf text, a key->value pair, or
+// a collection with the items walked and the number left out.
+type node struct {
+	text       string
+	key, value *node
+	open       string
+	items      []*node
+	more       int
+}
+
+func (n *node) flat() string {
+	switch {
+	case n.value != nil:
+		return n.key.flat() + "->" + n.value.flat()
+	case n.open == "":
+		return n.text
+	}
+	items := make([]string, len(n.items))
+	for i, item := range n.items {
+		items[i] = item.flat()
+	}
+	if n.more > 0 {
+		items = append(items, fmt.Sprintf("... %d more", n.more))
+	}
+	return n.open + strings.Join(items, " ") + ")"
+}
+
+// identity tells collections apart; a slice is known by its first element
+// and its length, so an Array and a shorter copy sharing storage differ.
+type identity struct {
+	ptr uintptr
+	len int
+}
+
+// collection answers the parts of an Array or Dictionary that has no custom
+// printString, so that the walker can take it apart.
+func collection(obj *core.Object) (open string, keys, values []*core.Object, id identity, ok bool) {
+	if _, custom := obj.Get("!printString"); custom {
+		return "", nil, nil, id, false
+	}
+	switch obj.Class {
+	case "Array":
+		values, ok = obj.Self.([]*core.Object)
+		return "#(", nil, values, identity{reflect.ValueOf(obj.Self).Pointer(), len(values)}, ok
+	case "Dictionary":
+		keysFn, _ := obj.Get("keys")
+		valuesFn, _ := obj.Get("values")
+		keys, _ = keysFn.(func() core.Object)().Self.([]*core.Object)
+		values, _ = valuesFn.(func() core.Object)().Self.([]*core.Object)
+		return "a Dictionary(", keys, values, identity{reflect.ValueOf(obj.Self).Pointer(), 0}, true
+	}
+	return "", nil, nil, id, false
+}
+
+// walker builds the node tree of a result. A collection met again inside
+// itself becomes "..."; when limited, at most maxElements items of each
+// collection, maxNodes items in all and maxDepth levels are walked.
+type walker struct {
+	limited bool
+	open    map[identity]bool
+	nodes   int
+}
+
+func (w *walker) walk(obj *core.Object, depth int) *node {
+	if obj == nil {
+		return &node{text: "nil"}
+	}
+	open, keys, values, id, ok := collection(obj)
+	if !ok {
+		return &node{text: obj.PrintString()}
+	}
+	if w.open[id] {
+		return &node{text: "..."}
+	}
+	if w.limited && depth >= maxDepth && len(values) > 0 {
+		return &node{text: open + "...)"}
+	}
+	w.open[id] = true
+	defer delete(w.open, id)
+
+	n := &node{open: open}
+	for i, value := range values {
+		if w.limited && (i == maxElements || w.nodes == maxNodes) {
+			n.more = len(values) - i
+			break
+		}
+		w.nodes++
+		item := w.walk(value, depth+1)
+		if keys != nil {
+			item = &node{key: w.walk(keys[i], depth+1), value: item}
+		}
+		n.items = append(n.items, item)
+	}
+	return n
+}
+
+// layout breaks a collection that does not fit in the width, or that was cut
+// short, into one item per line, and tries each item again at its indent.
+func (d *display) layout(n *node, indent int) string {
+	if n.value != nil {
+		key := d.layout(n.key, indent)
+		return key + "->" + d.layout(n.value, indent)
+	}
+	flat := n.flat()
+	if n.open == "" || n.more == 0 && indent+utf8.RuneCountInString(flat) <= d.width {
+		return flat
+	}
+	items := make([]string, len(n.items))
+	for i, item := range n.items {
+		items[i] = d.layout(item, indent+2)
+	}
+	if n.more > 0 {
+		items = append(items, fmt.Sprintf("... %d more", n.more))
+	}
+	pad := strings.Repeat(" ", indent+2)
+	return n.open + "\n" + pad + strings.Join(items, "\n"+pad) + "\n" + strings.Repeat(" ", indent) + ")"
+}
+
+// format renders a result without going through PrintString for Arrays and
+// Dictionaries, so a collection holding itself prints instead of recursing
+// forever. Piped output matches printString otherwise.
+func (d *display) format(obj *core.Object) string {
+	w := &walker{limited: d.pretty, open: map[identity]bool{}}
+	n := w.walk(obj, 0)
+	if !d.pretty {
+		return n.flat()
+	}
+	return d.layout(n, 0)
+}
+
+// render is format for a result that may be hidden: it answers "" when the
+// result is not printable, as String does.
+func (d *display) render(obj *core.Object) string {
+	if printable, ok := obj.Get("!printable"); ok && printable == false {
+		return ""
+	}
+	return d.format(obj)
+}
+
+// plainString renders a result the way piped output shows it.
+func plainString(obj *core.Object) string {
+	return (&display{}).render(obj)
+}
+
+func (d *display) print(outputs []core.Object) {
+	for _, out := range outputs {
+		if strings.HasSuffix(out.Class, "Error") {
+			if d.errColor {
+				fmt.Fprintln(os.Stderr, "\x1b[31m"+out.String()+reset)
+			} else {
+				fmt.Fprintln(os.Stderr, out.String())
+			}
+			continue
+		}
+		s := d.render(&out)
+		if d.color {
+			s = highlight(s)
+		}
+		fmt.Println(s)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"minitalk/types"
+	"minitalk/types/core"
+)
+
+func array(values ...core.Object) *core.Object {
+	elements := make([]*core.Object, len(values))
+	for i := range values {
+		elements[i] = &values[i]
+	}
+	return &types.NewArrayObject(elements).Object
+}
+
+func integers(n int) *core.Object {
+	values := make([]core.Object, n)
+	for i := range values {
+		values[i] = types.NewIntegerObject(int64(i + 1)).Object
+	}
+	return array(values...)
+}
+
+func TestFormatKeepsShortCollectionsFlat(t *testing.T) {
+	d := &display{pretty: true, width: 20}
+	obj := array(types.NewIntegerObject(1).Object, types.NewStringObject("two").Object, *integers(2))
+	if got := d.format(obj); got != "#(1 'two' #(1 2))" {
+		t.Errorf("Expected #(1 'two' #(1 2)) but got %q", got)
+	}
+}
+
+func TestFormatBreaksWideCollections(t *testing.T) {
+	d := &display{pretty: true, width: 20}
+	dict := types.NewDictionaryObject()
+	dict.AtPut(types.NewSymbolObject("short").Object, *integers(3))
+	dict.AtPut(types.NewSymbolObject("long").Object, *integers(12))
+	want := "a Dictionary(\n" +
+		"  #short->#(1 2 3)\n" +
+		"  #long->#(\n" +
+		"    1\n    2\n    3\n    4\n    5\n    6\n    7\n    8\n    9\n    10\n    11\n    12\n" +
+		"  )\n" +
+		")"
+	if got := d.format(&dict.Object); got != want {
+		t.Errorf("Expected\n%s\nbut got\n%s", want, got)
+	}
+}
+
+func TestFormatTruncatesLongCollections(t *testing.T) {
+	d := &display{pretty: true, width: 80}
+	lines := strings.Split(d.format(integers(maxElements+50)), "\n")
+	if len(lines) != maxElements+3 {
+		t.Fatalf("Expected %d lines but got %d", maxElements+3, len(lines))
+	}
+	if lines[maxElements+1] != "  ... 50 more" {
+		t.Errorf("Expected '  ... 50 more' but got %q", lines[maxElements+1])
+	}
+
+	plain := &display{width: 80}
+	if got, want := plain.format(integers(maxElements+50)), integers(maxElements+50).PrintString(); got != want {
+		t.Errorf("Expected piped output to match printString but got %q", got)
+	}
+}
+
+func TestFormatTruncatesDeepNesting(t *testing.T) {
+	d := &display{pretty: true, width: 80}
+	obj := integers(1)
+	for i := 0; i < maxDepth; i++ {
+		obj = array(*obj)
+	}
+	want := strings.Repeat("#(", maxDepth) + "#(...)" + strings.Repeat(")", maxDepth)
+	if got := d.format(obj); got != want {
+		t.Errorf("Expected %s but got %s", want, got)
+	}
+}
+
+func TestFormatCycles(t *testing.T) {
+	dict := types.NewDictionaryObject()
+	dict.AtPut(types.NewSymbolObject("me").Object, dict.Object)
+	shared := integers(2)
+	dict.AtPut(types.NewSymbolObject("pair").Object, *array(*shared, *shared))
+	want := "a Dictionary(#me->... #pair->#(#(1 2) #(1 2)))"
+	for _, d := range []*display{{pretty: true, width: 80}, {}} {
+		if got := d.format(&dict.Object); got != want {
+			t.Errorf("Expected %s but got %s", want, got)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	cases := []struct{ src, want string }{
+		{"x := 'a'", "x := \x1b[32m'a'\x1b[0m"},
+		{"3 max: #b", "\x1b[36m3\x1b[0m \x1b[34mmax\x1b[0m\x1b[34m:\x1b[0m \x1b[35m#b\x1b[0m"},
+		{"Foo new", "\x1b[1mFoo\x1b[0m new"},
+		{"#(1 nil)", "#(\x1b[36m1\x1b[0m \x1b[33mnil\x1b[0m)"},
+		{"'a\nb'", "\x1b[32m'a\x1b[0m\n\x1b[32mb'\x1b[0m"},
+	}
+	for _, c := range cases {
+		if got := highlight(c.src); got != c.want {
+			t.Errorf("highlight(%q): expected %q but got %q", c.src, c.want, got)
+		}
+	}
+}
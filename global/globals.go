@@ -2,4 +2,9 @@ package global
 
 import "github.com/peterh/liner"
 
-var Liner = liner.NewLiner()
+var (
+	Liner          = liner.NewLiner()
+	ReplReadsStdin = false
+	Arguments      []string
+	Version        = "dev"
+)
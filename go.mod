@@ -3,7 +3,8 @@ module minitalk
 go 1.23.11
 
 require (
-	github.com/mattn/go-runewidth v0.0.3 // indirect
-	github.com/peterh/liner v1.2.2 // indirect
-	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1 // indirect
+	github.com/peterh/liner v1.2.2
+	golang.org/x/sys v0.0.0-20211117180635-dee7805ff2e1
 )
+
+require github.com/mattn/go-runewidth v0.0.3 // indirect
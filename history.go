@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const historyLimit = 1000
+
+func historyPath() string {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "minitalk", "history")
+}
+
+func rcPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".minitalkrc.sm")
+}
+
+// History entries keep their line breaks; on disk each entry is one line with
+// backslashes and line breaks escaped.
+var (
+	historyEscaper   = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	historyUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n")
+)
+
+func (r *Repl) appendHistory(entry string) {
+	r.liner.AppendHistory(entry)
+	r.recall = append(r.recall, entry)
+}
+
+func (r *Repl) loadHistory() {
+	data, err := os.ReadFile(r.historyFile)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line != "" {
+			r.appendHistory(historyUnescaper.Replace(line))
+		}
+	}
+}
+
+// saveHistory keeps only the latest occurrence of each entry and at most
+// historyLimit entries.
+func (r *Repl) saveHistory() {
+	seen := make(map[string]bool)
+	var entries []string
+	for i := len(r.recall) - 1; i >= 0 && len(entries) < historyLimit; i-- {
+		line := historyEscaper.Replace(r.recall[i])
+		if line == "" || seen[line] {
+			continue
+		}
+		seen[line] = true
+		entries = append(entries, line)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+
+	err := os.MkdirAll(filepath.Dir(r.historyFile), 0700)
+	if err == nil {
+		err = os.WriteFile(r.historyFile, []byte(strings.Join(entries, "\n")+"\n"), 0600)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Warning: could not save history:", err)
+	}
+}
+
+// runStartupFile runs ~/.minitalkrc.sm, before any -i script so that the
+// script's globals win.
+func (r *Repl) runStartupFile() {
+	if r.rcFile == "" {
+		return
+	}
+	if _, err := os.Stat(r.rcFile); err != nil {
+		return
+	}
+	compileFile(r, r.rcFile)
+}
@@ -1,5 +1,11 @@
 package main
 
+import (
+	"strings"
+
+	"minitalk/tokens"
+)
+
 type InputHandler struct{}
 
 func NewInputHandler() *InputHandler {
@@ -7,41 +13,41 @@ func NewInputHandler() *InputHandler {
 }
 
 func (h *InputHandler) Complete(input string, promptFn func(string) (string, error)) (string, error) {
-	parensOpen, parensClose := 0, 0
-	brackOpen, brackClose := 0, 0
-	semicolonPending := false
-
-	count := func(s string) {
-		for _, c := range s {
-			switch c {
-			case '(':
-				parensOpen++
-			case ')':
-				parensClose++
-			case '[':
-				brackOpen++
-			case ']':
-				brackClose++
-			case ';':
-				semicolonPending = true
-			case '.':
-				if semicolonPending {
-					semicolonPending = false
-				}
-			}
+	for {
+		open, pending := h.Pending(input)
+		if len(open) == 0 && !pending {
+			return input, nil
 		}
-	}
-
-	count(input)
-
-	for parensOpen > parensClose || brackOpen > brackClose || semicolonPending {
-		cont, err := promptFn("... ")
+		cont, err := promptFn(strings.Join(open, "") + "... ")
 		if err != nil {
 			return "", err
 		}
 		input += "\n" + cont
-		count(cont)
 	}
+}
 
-	return input, nil
+// Pending reports the constructs still open at the end of input, innermost
+// last, and whether the final statement still expects more tokens.
+func (h *InputHandler) Pending(input string) ([]string, bool) {
+	toks := tokens.Lex(input)
+	var nest nesting
+	var significant []tokens.Token
+	for i, tok := range toks {
+		nest.add(toks, i)
+		if tok.Type != tokens.Whitespace && tok.Type != tokens.Comment {
+			significant = append(significant, tok)
+		}
+	}
+	if nest.depth() > 0 {
+		return nest.labels(), false
+	}
+
+	if len(significant) < 2 {
+		return nil, false
+	}
+	switch last := significant[len(significant)-1].Type; {
+	case last == tokens.Semicolon, last == tokens.Assignment, last == tokens.Colon, isBinaryOperator(last):
+		return nil, true
+	}
+	return nil, false
 }
@@ -1,66 +1,180 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
-	"minitalk/tokens"
+	"minitalk/classes"
+	"minitalk/global"
 )
 
-func lineFeeder(lines []string) func(string) (string, error) {
-	i := 0
-	return func(string) (string, error) {
-		if i >= len(lines) {
-			return "", io.EOF
+const usage = `Usage: minitalk [options] [script.sm | -] [--] [arguments...]
+
+Without a script, starts the interactive REPL. A script of "-" is read from
+standard input. Arguments after the script (or after --) are available as
+"Smalltalk arguments".
+
+Options:
+  -e expr       evaluate expr and print its results
+  -c file       check the syntax of file without running it
+  -i file       run file, then start the REPL with its globals
+  --no-history  do not load or save the REPL history
+  --no-rc       do not run ~/.minitalkrc.sm before the first prompt
+  --version     print the version and exit
+  --help        print this help and exit
+`
+
+func readSource(filename string) (string, error) {
+	if filename == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(filename)
+	return string(data), err
+}
+
+// evaluate runs every statement of source and reports uncaught errors on
+// stderr. With echo set, printable results are written to stdout as well.
+func evaluate(repl *Repl, source string, echo bool) (failed bool) {
+	before := syntaxErrors
+	statements, unterminated := splitStatements(source)
+	if unterminated {
+		statements = statements[:len(statements)-1]
+	}
+
+	for _, statement := range statements {
+		for _, out := range repl.ProcessLine(statement) {
+			if strings.HasSuffix(out.Class, "Error") {
+				classes.FlushTranscript()
+				fmt.Fprintln(os.Stderr, out.String())
+				failed = true
+			} else if echo {
+				if s := plainString(&out); s != "" {
+					classes.FlushTranscript()
+					fmt.Println(s)
+				}
+			}
 		}
-		s := lines[i]
-		i++
-		return s, nil
 	}
+	if unterminated {
+		classes.FlushTranscript()
+		syntaxError("unexpected end of input")
+	}
+	return failed || syntaxErrors > before
 }
 
-func compileFile(filename string) {
-	data, err := os.ReadFile(filename)
+func compileFile(repl *Repl, filename string) bool {
+	source, err := readSource(filename)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error:", err)
-		return
+		return false
 	}
+	return !evaluate(repl, source, false)
+}
 
-	repl := NewRepl()
-	handler := NewInputHandler()
-	lines := strings.Split(string(data), "\r\n")
-	feed := lineFeeder(lines)
-
-	for {
-		line, err := feed(">>> ")
-		if err != nil {
-			break
+func checkFile(filename string) bool {
+	source, err := readSource(filename)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return false
+	}
+	problems := checkSyntax(source)
+	for _, problem := range problems {
+		syntaxError(fmt.Sprintf("%s:%s", filename, problem))
+	}
+	if len(problems) > 0 {
+		return false
+	}
+	fmt.Printf("%s: Syntax OK\n", filename)
+	return true
+}
+
+func run(args []string) (status int) {
+	defer classes.Shutdown()
+	defer func() {
+		if r := recover(); r != nil {
+			exit, ok := r.(classes.ExitRequest)
+			if !ok {
+				panic(r)
+			}
+			status = exit.Code
 		}
-		if strings.TrimSpace(line) == "" {
-			continue
+	}()
+
+	flags := flag.NewFlagSet("minitalk", flag.ContinueOnError)
+	flags.Usage = func() {}
+	expr := flags.String("e", "", "")
+	check := flags.String("c", "", "")
+	interactive := flags.String("i", "", "")
+	version := flags.Bool("version", false, "")
+	noHistory := flags.Bool("no-history", false, "")
+	noRC := flags.Bool("no-rc", false, "")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			fmt.Print(usage)
+			return 0
 		}
-		input, err := handler.Complete(line, feed)
-		if err != nil {
-			if err != io.EOF {
-				fmt.Fprintln(os.Stderr, "Error:", err)
-			}
-			break
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	rest := flags.Args()
+	isSet := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { isSet[f.Name] = true })
+
+	switch {
+	case *version:
+		fmt.Println("minitalk", global.Version)
+		return 0
+	case isSet["c"]:
+		if !checkFile(*check) {
+			return 1
 		}
-		toks := filterWhitespace(tokens.Lex(input))
-		repl.ProcessLine(toks)
+		return 0
 	}
-}
 
-func main() {
-	args := os.Args
+	var script string
+	if !isSet["e"] && !isSet["i"] && len(rest) > 0 {
+		script, rest = rest[0], rest[1:]
+	}
+	if len(rest) > 0 && rest[0] == "--" {
+		rest = rest[1:]
+	}
+	global.Arguments = rest
 
-	if len(args) > 1 {
-		filename := args[1]
-		compileFile(filename)
-	} else {
-		repl := NewRepl()
+	repl := NewRepl()
+	if classes.StdinIsTerminal() {
+		if !*noHistory {
+			repl.historyFile = historyPath()
+		}
+		if !*noRC {
+			repl.rcFile = rcPath()
+		}
+	}
+	switch {
+	case isSet["e"]:
+		if evaluate(repl, *expr, true) {
+			return 1
+		}
+	case isSet["i"]:
+		repl.runStartupFile()
+		compileFile(repl, *interactive)
+		classes.FlushTranscript()
+		repl.Start()
+	case script != "":
+		if !compileFile(repl, script) {
+			return 1
+		}
+	default:
+		repl.runStartupFile()
 		repl.Start()
 	}
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 
@@ -19,6 +18,19 @@ import (
 type Repl struct {
 	globalScope map[string]core.Object
 	liner       *liner.State
+	historyFile string
+	rcFile      string
+	recall      []string
+	display     *display
+	out         *types.DictionaryObject
+	counter     int
+	history     []historyEntry
+}
+
+type historyEntry struct {
+	number int
+	input  string
+	output string
 }
 
 func (r *Repl) GetVar(name string) (core.Object, bool) {
@@ -46,17 +58,46 @@ func (r *Repl) GetNames() []string {
 }
 
 func NewRepl() *Repl {
-	r := &Repl{
-		globalScope: make(map[string]core.Object),
-		liner:       global.Liner,
-	}
+	r := &Repl{liner: global.Liner, display: newDisplay()}
+	r.initScope()
+	return r
+}
 
+func (r *Repl) initScope() {
+	r.globalScope = make(map[string]core.Object)
+	r.globalScope["Smalltalk"] = *classes.NewSmalltalkClass()
 	r.globalScope["Transcript"] = *classes.NewTranscriptClass()
+	r.globalScope["Stderr"] = *classes.NewStderrClass()
 	r.globalScope["stdin"] = *classes.NewStdinClass()
 	r.globalScope["FileSystem"] = *classes.NewFileSystemClass()
-	r.globalScope["nl"] = types.NewStringObject(`\n`).Object
+	r.globalScope["Character"] = *types.NewCharacterClass()
+	r.globalScope["String"] = *types.NewStringClass()
+	r.globalScope["Dictionary"] = *types.NewDictionaryClass()
+	r.globalScope["DateAndTime"] = *types.NewDateAndTimeClass()
+	r.globalScope["ReadStream"] = *types.NewStreamClass("ReadStream")
+	r.globalScope["WriteStream"] = *types.NewStreamClass("WriteStream")
+	r.globalScope["ReadWriteStream"] = *types.NewStreamClass("ReadWriteStream")
+	r.globalScope["nl"] = types.NewStringObject("\n").Object
+	r.out = types.NewDictionaryObject()
+	r.globalScope["Out"] = r.out.Object
+}
 
-	return r
+// record numbers an evaluated input and keeps its printable, non-error
+// result as _n and in Out.
+func (r *Repl) record(input string, outputs []core.Object) {
+	r.counter++
+	entry := historyEntry{number: r.counter, input: input}
+	if len(outputs) > 0 {
+		result := outputs[len(outputs)-1]
+		if s := plainString(&result); s != "" && !strings.HasSuffix(result.Class, "Error") {
+			entry.output = s
+			r.globalScope[fmt.Sprintf("_%d", r.counter)] = result
+			if result.Self != r.out.Self {
+				r.out.AtPut(types.NewIntegerObject(int64(r.counter)).Object, result)
+			}
+		}
+	}
+	r.history = append(r.history, entry)
 }
 
 func parseByteArray(value string, r *Repl, stack *[]core.Object) ([]byte, bool) {
@@ -87,11 +128,11 @@ func parseByteArray(value string, r *Repl, stack *[]core.Object) ([]byte, bool)
 			base, _ := strconv.ParseInt(parts[0], 10, 32)
 			intVal, err = strconv.ParseInt(parts[1], int(base), 64)
 			if base < 2 || base > 36 {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid base", base)
+				syntaxError("invalid base", base)
 				return nil, false
 			}
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid number in base", base)
+				syntaxError("invalid number in base", base)
 				return nil, false
 			}
 
@@ -197,11 +238,11 @@ func parseArray(value string, r *Repl, stack *[]core.Object) ([]*core.Object, bo
 			var intVal int64
 			intVal, err = strconv.ParseInt(parts[1], int(base), 64)
 			if base < 2 || base > 36 {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid base", base)
+				syntaxError("invalid base", base)
 				return nil, false
 			}
 			if err != nil {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid number in base", base)
+				syntaxError("invalid number in base", base)
 				return nil, false
 			}
 			if minus {
@@ -219,49 +260,49 @@ func parseArray(value string, r *Repl, stack *[]core.Object) ([]*core.Object, bo
 
 		case tokens.Character:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Character")
+				syntaxError("invalid unary minus for Character")
 				return nil, false
 			}
 			obj = types.NewCharacterObject([]rune(t.Value[1:])[0]).Object
 
 		case tokens.String:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for String")
+				syntaxError("invalid unary minus for String")
 				return nil, false
 			}
 			obj = types.NewStringObject(t.Value[1 : len(t.Value)-1]).Object
 
 		case tokens.Symbol:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Symbol")
+				syntaxError("invalid unary minus for Symbol")
 				return nil, false
 			}
 			obj = types.NewSymbolObject(t.Value[1:]).Object
 
 		case tokens.True:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Bool")
+				syntaxError("invalid unary minus for Bool")
 				return nil, false
 			}
 			obj = types.NewBoolObject(true).Object
 
 		case tokens.False:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Character")
+				syntaxError("invalid unary minus for Character")
 				return nil, false
 			}
 			obj = types.NewBoolObject(false).Object
 
 		case tokens.Nil:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Nil")
+				syntaxError("invalid unary minus for Nil")
 				return nil, false
 			}
 			obj = *core.NewObject(nil, "Nil")
 
 		case tokens.Identifier:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for variables")
+				syntaxError("invalid unary minus for variables")
 				return nil, false
 			}
 			var inScope bool
@@ -272,7 +313,7 @@ func parseArray(value string, r *Repl, stack *[]core.Object) ([]*core.Object, bo
 
 		case tokens.ByteArray:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for ByteArray")
+				syntaxError("invalid unary minus for ByteArray")
 				return nil, false
 			}
 			bytes, ok := parseByteArray(t.Value, r, stack)
@@ -284,7 +325,7 @@ func parseArray(value string, r *Repl, stack *[]core.Object) ([]*core.Object, bo
 
 		case tokens.Array:
 			if minus {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Array")
+				syntaxError("invalid unary minus for Array")
 				return nil, false
 			}
 			nested, ok := parseArray(t.Value, r, stack)
@@ -340,7 +381,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 		return nil
 	}
 
-	for _, tok := range toks {
+	for i, tok := range toks {
 		plus = false
 
 		if assigment {
@@ -361,7 +402,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 					parenDepth = 0
 					subTokens = nil
 					if len(subResult) == 0 {
-						fmt.Fprintln(os.Stderr, "SyntaxError: empty parenthesis")
+						syntaxError("empty parenthesis")
 						return nil
 					}
 					obj := subResult[len(subResult)-1]
@@ -469,7 +510,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				} else {
 					pipe = true
 					if nonPipe {
-						fmt.Fprintln(os.Stderr, "SyntaxError: invalid characters in arguments list of a code block")
+						syntaxError("invalid characters in arguments list of a code block")
 						return nil
 					}
 				}
@@ -483,7 +524,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 							[]string{TokenTypeToString(tok.Type), tok.Value})
 					}
 				} else {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid characters in arguments list of a code block")
+					syntaxError("invalid characters in arguments list of a code block")
 					return nil
 				}
 			default:
@@ -498,7 +539,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 		switch tok.Type {
 		case tokens.Illegal:
-			fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+			syntaxError("invalid syntax")
 			return nil
 
 		case tokens.LParen:
@@ -511,7 +552,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 		case tokens.Semicolon:
 			if lastMessenger.Self == nil {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+				syntaxError("invalid syntax")
 				return nil
 			}
 			stack = []core.Object{lastMessenger}
@@ -539,7 +580,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 		case tokens.Assignment:
 			if lastVar == "" {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+				syntaxError("invalid syntax")
 				return nil
 			}
 			assigment = true
@@ -550,6 +591,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 		case tokens.Colon:
 			if !keywordMessage.IsInitialized() {
 				lastMessage = binaryMessage
+				binaryMessage = nil
 			}
 
 		case tokens.Symbol, tokens.Character, tokens.String, tokens.Integer, tokens.Float,
@@ -561,7 +603,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 			switch tok.Type {
 			case tokens.Symbol:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Symbol")
+					syntaxError("invalid unary minus for Symbol")
 					return nil
 				}
 				typeName = "Symbol"
@@ -569,7 +611,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 			case tokens.Character:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Character")
+					syntaxError("invalid unary minus for Character")
 					return nil
 				}
 				typeName = "Character"
@@ -578,7 +620,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 			case tokens.String:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for String")
+					syntaxError("invalid unary minus for String")
 					return nil
 				}
 				typeName = "String"
@@ -609,11 +651,11 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				base, _ := strconv.ParseInt(parts[0], 10, 32)
 				num, err := strconv.ParseInt(parts[1], int(base), 64)
 				if base < 2 || base > 36 {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid base", base)
+					syntaxError("invalid base", base)
 					return nil
 				}
 				if err != nil {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid number in base", base)
+					syntaxError("invalid number in base", base)
 					return nil
 				}
 				if minus {
@@ -624,7 +666,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 			case tokens.True:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Bool")
+					syntaxError("invalid unary minus for Bool")
 					return nil
 				}
 				typeName = "Bool"
@@ -632,7 +674,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 			case tokens.False:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Bool")
+					syntaxError("invalid unary minus for Bool")
 					return nil
 				}
 				typeName = "Bool"
@@ -640,7 +682,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 			case tokens.Nil:
 				if minus {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for Nil")
+					syntaxError("invalid unary minus for Nil")
 					return nil
 				}
 				typeName = "Nil"
@@ -692,7 +734,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				lastMessage = nil
 			} else {
 				if binaryMessage != nil {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+					syntaxError("invalid syntax")
 					return nil
 				}
 				stack = append(stack, obj)
@@ -730,7 +772,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				lastMessage = nil
 			} else {
 				if binaryMessage != nil {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+					syntaxError("invalid syntax")
 					return nil
 				}
 				stack = append(stack, obj)
@@ -752,7 +794,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				} else {
 					keywordMessage.ApplyToObject()
 					obj := keywordMessage.Obj
-					val, ok := obj.Get(keywordMessage.Message)
+					val, ok := obj.GetKeyword(keywordMessage.Message)
 					if !ok {
 						messageError = true
 					}
@@ -786,12 +828,12 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 					continue
 				}
 			} else if inScope && binaryMessage != nil {
-				fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+				syntaxError("invalid syntax")
 				return nil
 			} else if len(stack) == 0 {
 				if inScope {
 					if minus {
-						fmt.Fprintln(os.Stderr, "SyntaxError: invalid unary minus for variables")
+						syntaxError("invalid unary minus for variables")
 						return nil
 					}
 					stack = append(stack, obj)
@@ -802,7 +844,12 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				lastMessenger = last
 				stack = stack[:len(stack)-1]
 				lastType = last.Class
-				val, ok := last.Get(tok.Value)
+				selector := tok.Value
+				get := last.GetUnary
+				if i+1 < len(toks) && toks[i+1].Type == tokens.Colon {
+					get = last.GetKeyword
+				}
+				val, ok := get(selector)
 				if !ok {
 					messageError = true
 					continue
@@ -810,8 +857,8 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				if zeroArgsFn, ok := val.(func() core.Object); ok {
 					stack = append(stack, zeroArgsFn())
 				} else if fn, ok := val.(func(core.Object) interface{}); ok {
-					if last.HasOptional(tok.Value) {
-						keywordMessage = *core.NewKeywordMessage(&last, tok.Value)
+					if last.HasOptional(selector) {
+						keywordMessage = *core.NewKeywordMessage(&last, selector)
 					} else {
 						binaryMessage = fn
 					}
@@ -852,7 +899,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				}
 			}
 
-		case tokens.Plus, tokens.Minus, tokens.Star, tokens.Slash, tokens.Ampersand,
+		case tokens.Plus, tokens.Minus, tokens.Star, tokens.Slash, tokens.Ampersand, tokens.Percent,
 			tokens.LessThan, tokens.GreaterThan, tokens.LessThanEqual, tokens.GreaterThanEqual, tokens.DoubleEquals:
 			opMethods := map[tokens.TokenType]string{
 				tokens.Plus:             "plus",
@@ -860,6 +907,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				tokens.Star:             "mul",
 				tokens.Slash:            "div",
 				tokens.Ampersand:        "and",
+				tokens.Percent:          "mod",
 				tokens.LessThan:         "lt",
 				tokens.GreaterThan:      "gt",
 				tokens.LessThanEqual:    "le",
@@ -891,7 +939,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 				}
 			} else {
 				if len(stack) == 0 {
-					fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+					syntaxError("invalid syntax")
 					return nil
 				}
 			}
@@ -899,7 +947,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 			if keywordMessage.IsInitialized() {
 				keywordMessage.ApplyToObject()
 				obj := keywordMessage.Obj
-				val, ok := obj.Get(keywordMessage.Message)
+				val, ok := obj.GetKeyword(keywordMessage.Message)
 				if !ok {
 					messageError = true
 				}
@@ -946,7 +994,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 	if keywordMessage.IsInitialized() {
 		keywordMessage.ApplyToObject()
 		obj := keywordMessage.Obj
-		val, ok := obj.Get(keywordMessage.Message)
+		val, ok := obj.GetKeyword(keywordMessage.Message)
 		if !ok {
 			messageError = true
 		}
@@ -969,7 +1017,7 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 		r.globalScope["_"] = result
 		results = append(results, result)
 	} else if minus || plus {
-		fmt.Fprintln(os.Stderr, "SyntaxError: invalid syntax")
+		syntaxError("invalid syntax")
 		return nil
 	}
 	return results
@@ -977,13 +1025,23 @@ func (r *Repl) ProcessLine(toks []tokens.Token) []core.Object {
 
 func (r *Repl) Start() {
 	defer r.liner.Close()
+	global.ReplReadsStdin = true
 	r.liner.SetCtrlCAborts(true)
 	r.liner.SetMultiLineMode(false)
+	r.liner.SetWordCompleter(r.complete)
+	if r.historyFile != "" {
+		r.loadHistory()
+		defer r.saveHistory()
+	}
 
 	handler := NewInputHandler()
 
 	for {
-		line, err := r.liner.Prompt(">>> ")
+		prompt := ">>> "
+		if classes.StdinIsTerminal() {
+			prompt = fmt.Sprintf("[%d] >>> ", r.counter+1)
+		}
+		line, err := r.liner.Prompt(prompt)
 		if err != nil {
 			fmt.Println()
 			break
@@ -992,18 +1050,32 @@ func (r *Repl) Start() {
 		if strings.TrimSpace(line) == "exit" {
 			break
 		}
+		if isCommand(line) {
+			r.appendHistory(strings.TrimSpace(line))
+			r.RunCommand(line)
+			continue
+		}
 
-		input, err := handler.Complete(line, r.liner.Prompt)
+		prompts := []string{prompt}
+		input, err := handler.Complete(line, func(prompt string) (string, error) {
+			prompts = append(prompts, prompt)
+			return r.liner.Prompt(prompt)
+		})
 		if err != nil {
 			fmt.Println()
 			break
 		}
+		if r.display.color && classes.StdinIsTerminal() {
+			r.display.echo(prompts, input)
+		}
 
-		r.liner.AppendHistory(input)
+		r.appendHistory(input)
 		toks := filterWhitespace(tokens.Lex(input))
 		outputs := r.ProcessLine(toks)
-		for _, out := range outputs {
-			fmt.Println(out.String())
+		classes.FlushTranscript()
+		r.display.print(outputs)
+		if strings.TrimSpace(input) != "" {
+			r.record(input, outputs)
 		}
 	}
 }
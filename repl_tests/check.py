@@ -1,5 +1,8 @@
+import re
 import subprocess
 
+ERROR_LINE = re.compile(r"^[A-Za-z]*Error: ")
+
 def read_tests(filename):
     with open(filename) as f:
         lines = [l.strip() for l in f if l.strip() and not l.startswith("@")]
@@ -31,7 +34,7 @@ def run_repl(inputs):
     return out_lines, err_lines
 
 def check_results(inputs, expected_outputs, actual_outputs, stream_name):
-    passed, failed = 0, 0
+    failed = set()
     idx = 0
     for i, expected in enumerate(expected_outputs):
         got = actual_outputs[idx:idx + len(expected)]
@@ -40,11 +43,14 @@ def check_results(inputs, expected_outputs, actual_outputs, stream_name):
             print(f"  Input:    {inputs[i]}")
             print(f"  Expected: {expected}")
             print(f"  Got:      {got}\n")
-            failed += 1
-        else:
-            passed += 1
+            failed.add(i)
         idx += len(expected)
-    return passed, failed
+    return failed
+
+def split_streams(expected_outputs):
+    out = [[l for l in exp if not ERROR_LINE.match(l)] for exp in expected_outputs]
+    err = [[l for l in exp if ERROR_LINE.match(l)] for exp in expected_outputs]
+    return out, err
 
 def main():
     SETUP = 1
@@ -52,11 +58,14 @@ def main():
     good_inputs, good_expected = read_tests("repl_tests/tests.txt")
 
     _, err_output = run_repl(err_inputs)
-    err_passed, err_failed = check_results(err_inputs, err_expected, err_output, "stderr")
-    err_passed -= SETUP
+    err_failed = len(check_results(err_inputs, err_expected, err_output, "stderr"))
+    err_passed = len(err_inputs) - err_failed - SETUP
 
-    good_output, _ = run_repl(good_inputs)
-    good_passed, good_failed = check_results(good_inputs, good_expected, good_output, "stdout")
+    good_output, good_err_output = run_repl(good_inputs)
+    out_expected, runtime_expected = split_streams(good_expected)
+    good_failed = len(check_results(good_inputs, out_expected, good_output, "stdout")
+                      | check_results(good_inputs, runtime_expected, good_err_output, "stderr"))
+    good_passed = len(good_inputs) - good_failed
 
     print("\n--- Test Summary ---")
     print(f"Syntax Errors: {err_passed} passed, {err_failed} failed")
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"minitalk/tokens"
+)
+
+func isBinaryOperator(t tokens.TokenType) bool {
+	switch t {
+	case tokens.Plus, tokens.Minus, tokens.Star, tokens.Slash, tokens.Ampersand, tokens.Percent,
+		tokens.LessThan, tokens.GreaterThan, tokens.LessThanEqual, tokens.GreaterThanEqual, tokens.DoubleEquals:
+		return true
+	}
+	return false
+}
+
+// construct is a bracket, parenthesis, literal array, string or comment
+// opened in a token stream and not closed yet.
+type construct struct {
+	label string
+	tok   tokens.Token
+}
+
+// nesting follows the constructs a token stream opens and closes. The lexer
+// reports an unterminated string or comment as an Illegal quote, and an
+// unterminated literal array as an Illegal "#" before its bracket; a string or
+// comment left open runs to the end of the input.
+type nesting struct {
+	open []construct
+}
+
+func (n *nesting) depth() int {
+	return len(n.open)
+}
+
+func (n *nesting) labels() []string {
+	labels := make([]string, len(n.open))
+	for i, c := range n.open {
+		labels[i] = c.label
+	}
+	return labels
+}
+
+// inText reports whether the rest of the input belongs to a string or comment.
+func (n *nesting) inText() bool {
+	if len(n.open) == 0 {
+		return false
+	}
+	label := n.open[len(n.open)-1].label
+	return label == "'" || label == `"`
+}
+
+// opensLiteral reports whether toks[i] is the "#" the lexer leaves before the
+// bracket of an unterminated literal array.
+func opensLiteral(toks []tokens.Token, i int) bool {
+	return i+1 < len(toks) && toks[i].Type == tokens.Illegal && toks[i].Value == "#" &&
+		(toks[i+1].Type == tokens.LParen || toks[i+1].Type == tokens.LBracket)
+}
+
+// add accounts for toks[i] and answers false for a closing token that matches
+// no open construct.
+func (n *nesting) add(toks []tokens.Token, i int) bool {
+	if n.inText() {
+		return true
+	}
+	tok := toks[i]
+	switch tok.Type {
+	case tokens.Illegal:
+		if tok.Value == "'" || tok.Value == `"` {
+			n.open = append(n.open, construct{tok.Value, tok})
+		}
+	case tokens.LParen, tokens.LBracket:
+		if i > 0 && opensLiteral(toks, i-1) {
+			n.open = append(n.open, construct{"#" + tok.Value, toks[i-1]})
+		} else {
+			n.open = append(n.open, construct{tok.Value, tok})
+		}
+	case tokens.RParen, tokens.RBracket:
+		opening := map[string]string{")": "(", "]": "["}[tok.Value]
+		if len(n.open) == 0 || strings.TrimPrefix(n.open[len(n.open)-1].label, "#") != opening {
+			return false
+		}
+		n.open = n.open[:len(n.open)-1]
+	}
+	return true
+}
+
+func significant(toks []tokens.Token, from int) (int, bool) {
+	for i := from; i < len(toks); i++ {
+		if toks[i].Type != tokens.Whitespace && toks[i].Type != tokens.Comment {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// A line break ends a statement only when the statement is complete and the
+// next line does not carry on with a keyword, binary operator or cascade.
+func endsStatement(toks []tokens.Token, last tokens.Token, next int) bool {
+	switch {
+	case last.Type == tokens.Assignment, last.Type == tokens.Colon, last.Type == tokens.Semicolon,
+		isBinaryOperator(last.Type):
+		return false
+	}
+	i, ok := significant(toks, next)
+	if !ok {
+		return true
+	}
+	switch tok := toks[i]; {
+	case tok.Type == tokens.Semicolon, tok.Type == tokens.Period, isBinaryOperator(tok.Type):
+		return false
+	case tok.Type == tokens.Identifier:
+		if j, ok := significant(toks, i+1); ok && toks[j].Type == tokens.Colon {
+			return false
+		}
+	}
+	return true
+}
+
+// scriptSource drops a byte order mark and blanks out a leading "#!" line,
+// keeping its line break so that line numbers stay the same.
+func scriptSource(source string) string {
+	source = strings.TrimPrefix(source, "\ufeff")
+	if strings.HasPrefix(source, "#!") {
+		if i := strings.IndexAny(source, "\r\n"); i >= 0 {
+			return source[i:]
+		}
+		return ""
+	}
+	return source
+}
+
+func splitStatements(source string) (statements [][]tokens.Token, unterminated bool) {
+	source = scriptSource(source)
+	toks := tokens.Lex(source)
+
+	var current []tokens.Token
+	var nest nesting
+	flush := func() {
+		if len(current) > 0 {
+			statements = append(statements, current)
+			current = nil
+		}
+	}
+	for i, tok := range toks {
+		switch tok.Type {
+		case tokens.Comment:
+			continue
+		case tokens.Whitespace:
+			if nest.depth() == 0 && len(current) > 0 && strings.ContainsAny(tok.Value, "\r\n") &&
+				endsStatement(toks, current[len(current)-1], i+1) {
+				flush()
+			}
+			continue
+		}
+		nest.add(toks, i)
+		current = append(current, tok)
+		if tok.Type == tokens.Period && nest.depth() == 0 {
+			flush()
+		}
+	}
+	unterminated = nest.depth() > 0
+	flush()
+	return statements, unterminated
+}
+
+// checkSyntax reports, as "line: problem", the tokens the lexer cannot place,
+// any constructs that are unmatched or left open, and then any statement the
+// parser behind :ast rejects. Nothing is evaluated.
+func checkSyntax(source string) []string {
+	source = scriptSource(source)
+	lineOf := func(tok tokens.Token) int { return strings.Count(source[:tok.Start], "\n") + 1 }
+	unclosed := map[string]string{
+		"(": "unclosed (", "[": "unclosed [", "#(": "unterminated literal array",
+		"#[": "unterminated byte array", "'": "unterminated string", `"`: "unterminated comment",
+	}
+
+	var problems []string
+	var nest nesting
+	toks := tokens.Lex(source)
+	for i, tok := range toks {
+		if nest.inText() {
+			break
+		}
+		if !nest.add(toks, i) {
+			problems = append(problems, fmt.Sprintf("%d: unmatched %s", lineOf(tok), tok.Value))
+			continue
+		}
+		if tok.Type == tokens.Illegal && tok.Value != "'" && tok.Value != `"` && !opensLiteral(toks, i) {
+			problems = append(problems, fmt.Sprintf("%d: unexpected %q", lineOf(tok), tok.Value))
+		}
+	}
+	for _, c := range nest.open {
+		problems = append(problems, fmt.Sprintf("%d: %s", lineOf(c.tok), unclosed[c.label]))
+	}
+	if len(problems) > 0 {
+		return problems
+	}
+
+	statements, _ := splitStatements(source)
+	for _, statement := range statements {
+		p := &astParser{toks: statement}
+		if _, err := p.program(); err != nil {
+			problems = append(problems, fmt.Sprintf("%d: %s", lineOf(statement[min(p.pos, len(statement)-1)]), err))
+		}
+	}
+	return problems
+}
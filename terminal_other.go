@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "os"
+
+func terminalWidth(f *os.File) int {
+	return 0
+}
+
+func enableEscapes(f *os.File) bool {
+	return true
+}
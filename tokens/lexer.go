@@ -26,6 +26,7 @@ const (
 	Star
 	Slash
 	Ampersand
+	Percent
 	LessThan
 	GreaterThan
 	LessThanEqual
@@ -79,6 +80,7 @@ var tokenExprs = []tokenExpr{
 	{Star, regexp.MustCompile(`^\*`)},
 	{Slash, regexp.MustCompile(`^/`)},
 	{Ampersand, regexp.MustCompile(`^&`)},
+	{Percent, regexp.MustCompile(`^%`)},
 	{LParen, regexp.MustCompile(`^\(`)},
 	{RParen, regexp.MustCompile(`^\)`)},
 	{LBracket, regexp.MustCompile(`^\[`)},
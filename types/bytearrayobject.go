@@ -227,6 +227,36 @@ func NewByteArrayObject(data []byte) *ByteArrayObject {
 		}
 		return NewArrayObject(mapped).Object
 	})
+	obj.Set("asString", func() core.Object {
+		if idx := invalidUTF8Index(data); idx >= 0 {
+			return errors.NewValueError(fmt.Sprintf("Cannot decode ByteArray as UTF-8: invalid byte at %d", idx)).Object
+		}
+		return NewStringObject(string(data)).Object
+	})
+	for _, spec := range integerReaders {
+		obj.SetOptional(spec.name, "bigEndian", core.NewObject(nil, ""))
+		obj.Set(spec.name, func(other core.Object) interface{} {
+			if other.Class != "Integer" {
+				return nil
+			}
+			bigEndian := false
+			if endianObj, _ := obj.GetOptional(spec.name, "bigEndian"); endianObj.Class != "" {
+				if endianObj.Class != "Bool" {
+					return errors.NewValueError(spec.name + ": expects a Bool for bigEndian:").Object
+				}
+				bigEndian = endianObj.Self.(bool)
+			}
+			idx := other.Self.(int64)
+			if idx < 0 || idx+int64(spec.size) > int64(len(data)) {
+				return errors.NewValueError(fmt.Sprintf("Index %d out of range for %d bytes", idx, spec.size)).Object
+			}
+			v := readInteger(data[idx:idx+int64(spec.size)], spec.signed, bigEndian)
+			if !spec.signed && v < 0 {
+				return errors.NewValueError(fmt.Sprintf("%s: %d does not fit in an Integer", spec.name, uint64(v))).Object
+			}
+			return NewIntegerObject(v).Object
+		})
+	}
 	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to Integer").Object)
 	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
 	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
@@ -242,3 +272,33 @@ func NewByteArrayObject(data []byte) *ByteArrayObject {
 
 	return &ByteArrayObject{*obj}
 }
+
+var integerReaders = []struct {
+	name   string
+	size   int
+	signed bool
+}{
+	{"uint8At", 1, false},
+	{"int8At", 1, true},
+	{"uint16At", 2, false},
+	{"int16At", 2, true},
+	{"uint32At", 4, false},
+	{"int32At", 4, true},
+	{"uint64At", 8, false},
+	{"int64At", 8, true},
+}
+
+func readInteger(b []byte, signed, bigEndian bool) int64 {
+	var v uint64
+	for i := range b {
+		shift := i
+		if bigEndian {
+			shift = len(b) - 1 - i
+		}
+		v |= uint64(b[i]) << (8 * shift)
+	}
+	if signed && len(b) < 8 && v&(1<<(8*len(b)-1)) != 0 {
+		v |= ^uint64(0) << (8 * len(b))
+	}
+	return int64(v)
+}
@@ -2,6 +2,8 @@ package types
 
 import (
 	"fmt"
+	"strings"
+	"unicode"
 
 	"minitalk/types/core"
 	"minitalk/types/errors"
@@ -44,6 +46,15 @@ func NewCharacterObject(value rune) *CharacterObject {
 		}
 		return NewBoolObject(value == other.Self.(rune)).Object
 	})
+	obj.Set("value", int64(value), ObjectConstructor)
+	obj.Set("isLetter", unicode.IsLetter(value), ObjectConstructor)
+	obj.Set("isDigit", unicode.IsDigit(value), ObjectConstructor)
+	obj.Set("isVowel", strings.ContainsRune("aeiouAEIOU", value), ObjectConstructor)
+	obj.Set("isSeparator", unicode.IsSpace(value), ObjectConstructor)
+	obj.Set("isUppercase", unicode.IsUpper(value), ObjectConstructor)
+	obj.Set("isLowercase", unicode.IsLower(value), ObjectConstructor)
+	obj.Set("asUppercase", unicode.ToUpper(value), ObjectConstructor)
+	obj.Set("asLowercase", unicode.ToLower(value), ObjectConstructor)
 	obj.Set("toInteger", int64(value), ObjectConstructor)
 	obj.Set("toFloat", float64(value), ObjectConstructor)
 	obj.Set("toBool", value != 0, ObjectConstructor)
@@ -55,3 +66,24 @@ func NewCharacterObject(value rune) *CharacterObject {
 
 	return &CharacterObject{*obj}
 }
+
+func NewCharacterClass() *core.Object {
+	obj := core.NewObject("", "Character class")
+
+	obj.Set("value", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		code, _ := other.Self.(int64)
+		if code < 0 || code > unicode.MaxRune {
+			return errors.NewValueError("Value is not in valid Unicode range 0..0x10FFFF").Object
+		}
+		return NewCharacterObject(rune(code)).Object
+	})
+	obj.Set("cr", '\r', ObjectConstructor)
+	obj.Set("lf", '\n', ObjectConstructor)
+	obj.Set("tab", '\t', ObjectConstructor)
+	obj.Set("space", ' ', ObjectConstructor)
+
+	return obj
+}
@@ -121,6 +121,20 @@ func NewCodeBlockObject(arguments []string, loc [][][]string, r interfaces.ReplI
 
 		return core.Object{}
 	})
+	obj.Set("ensure", func(other core.Object) interface{} {
+		if other.Class != "CodeBlock" {
+			return nil
+		}
+		for _, block := range []*core.Object{obj, &other} {
+			if noArgs, ok := block.Get("no_arguments"); !ok || noArgs != int64(0) {
+				return errors.NewValueError("CodeBlock must have no arguments").Object
+			}
+		}
+		ensureFn, _ := other.Get("value")
+		defer ensureFn.(func(...core.Object) interface{})()
+		valueFn, _ := obj.Get("value")
+		return valueFn.(func(...core.Object) interface{})()
+	})
 	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to CodeBlock").Object)
 	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to CodeBlock").Object)
 	obj.Set("toBool", errors.NewTypeError("Invalid conversion to CodeBlock").Object)
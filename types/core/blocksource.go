@@ -0,0 +1,97 @@
+package core
+
+import "strings"
+
+func stringsOf(val interface{}) []string {
+	obj, ok := val.(Object)
+	if !ok {
+		return nil
+	}
+	elems, _ := obj.Self.([]*Object)
+	out := make([]string, len(elems))
+	for i, elem := range elems {
+		out[i], _ = elem.Self.(string)
+	}
+	return out
+}
+
+// blockSource rebuilds the source text of a CodeBlock from the token pairs it
+// keeps in "loc", one list per statement.
+func blockSource(o *Object) string {
+	args, _ := o.Get("arguments")
+	loc, _ := o.Get("loc")
+	locObj, _ := loc.(Object)
+	lines, _ := locObj.Self.([]*Object)
+
+	var b strings.Builder
+	b.WriteString("[")
+	for _, arg := range stringsOf(args) {
+		b.WriteString(":" + arg + " ")
+	}
+	if b.Len() > 1 {
+		b.WriteString("| ")
+	}
+	statements := []string{}
+	for _, line := range lines {
+		pairs, _ := line.Self.([]*Object)
+		types := make([]string, len(pairs))
+		values := make([]string, len(pairs))
+		for i, pair := range pairs {
+			parts := stringsOf(*pair)
+			if len(parts) == 2 {
+				types[i], values[i] = parts[0], parts[1]
+			}
+		}
+		if statement := joinTokens(types, values); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	b.WriteString(strings.Join(statements, ". "))
+	b.WriteString("]")
+	return b.String()
+}
+
+func joinTokens(types, values []string) string {
+	var b strings.Builder
+	paramColon := func(i int) bool { return paramColonAt(types, i) }
+	for i, value := range values {
+		if types[i] == "Identifier" {
+			value = strings.TrimPrefix(value, ":")
+		}
+		if i > 0 {
+			prev := types[i-1]
+			space := true
+			switch {
+			case prev == "LParen" || prev == "LBracket":
+				space = false
+			case types[i] == "Colon":
+				space = paramColon(i)
+			case types[i] == "Period" || types[i] == "RParen" || types[i] == "RBracket" || types[i] == "Semicolon":
+				space = false
+			case prev == "Colon" && paramColon(i-1):
+				space = false
+			case prev == "Minus" && (i == 1 || !isOperand(types[i-2])):
+				space = false
+			}
+			if space {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(value)
+	}
+	return b.String()
+}
+
+func paramColonAt(types []string, i int) bool {
+	return i == 0 || types[i-1] == "LBracket" ||
+		(i >= 2 && types[i-1] == "Identifier" && types[i-2] == "Colon" && paramColonAt(types, i-2))
+}
+
+func isOperand(t string) bool {
+	switch t {
+	case "Identifier", "Integer", "Float", "RadixNumber", "String", "Symbol", "Character", "Array", "ByteArray",
+		"Nil", "True", "False", "RParen", "RBracket":
+		return true
+	}
+	return false
+}
@@ -2,9 +2,24 @@ package core
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
+// Wrap turns a raw Go value into a full Minitalk object. It is installed by the
+// types package, which core cannot import.
+var Wrap func(interface{}) *Object
+
+// Overloaded is a selector sent both alone and with an argument, such as
+// printString and printString:. A keyword send reaches Keyword, and its
+// further parts are optional parts of the same selector.
+type Overloaded struct {
+	Unary   func() Object
+	Keyword func(Object) interface{}
+}
+
 type Object struct {
 	Self               interface{}
 	properties         map[string]interface{}
@@ -29,6 +44,7 @@ func NewObject(Self interface{}, Class string) *Object {
 	obj.Set("onTypeError", func(other Object) interface{} { return 0 })
 	obj.Set("onValueError", func(other Object) interface{} { return 0 })
 	obj.Set("onZeroDivisionError", func(other Object) interface{} { return 0 })
+	obj.Set("onFileSystemError", func(other Object) interface{} { return 0 })
 	obj.Set("toInteger", NotImplemented)
 	obj.Set("toFloat", NotImplemented)
 	obj.Set("toBool", NotImplemented)
@@ -37,6 +53,28 @@ func NewObject(Self interface{}, Class string) *Object {
 	obj.Set("toString", NotImplemented)
 	obj.Set("toByteArray", NotImplemented)
 	obj.Set("toArray", NotImplemented)
+	obj.Set("printString", Overloaded{
+		Unary: func() Object { return *Wrap(obj.PrintString()) },
+		Keyword: func(other Object) interface{} {
+			if other.Class != "CodeBlock" {
+				return nil
+			}
+			if noArgs, ok := other.Get("no_arguments"); !ok || noArgs != int64(1) {
+				return nil
+			}
+			obj.Set("!printString", other)
+			return *obj
+		},
+	})
+	obj.Set("displayString", func() Object { return *Wrap(obj.DisplayString()) })
+	obj.Set("printOn", func(stream Object) interface{} {
+		for _, selector := range []string{"nextPutAll", "show"} {
+			if fn, ok := stream.properties[selector].(func(Object) interface{}); ok {
+				return fn(*Wrap(obj.PrintString()))
+			}
+		}
+		return nil
+	})
 	return obj
 }
 
@@ -52,6 +90,24 @@ func (o *Object) Get(key string) (interface{}, bool) {
 	return v, ok
 }
 
+// GetKeyword answers the method a keyword send of key reaches.
+func (o *Object) GetKeyword(key string) (interface{}, bool) {
+	v, ok := o.properties[key]
+	if overloaded, isOverloaded := v.(Overloaded); isOverloaded {
+		return overloaded.Keyword, ok
+	}
+	return v, ok
+}
+
+// GetUnary answers the method a unary send of key reaches.
+func (o *Object) GetUnary(key string) (interface{}, bool) {
+	v, ok := o.properties[key]
+	if overloaded, isOverloaded := v.(Overloaded); isOverloaded {
+		return overloaded.Unary, ok
+	}
+	return v, ok
+}
+
 func (o *Object) GetPropertyType(key string) func(interface{}) *Object {
 	if constructor, ok := o.propertyTypes[key]; ok {
 		return constructor
@@ -111,13 +167,60 @@ func (o *Object) PropertyNames() []string {
 	return names
 }
 
+func FormatFloat(v float64) string {
+	if abs := math.Abs(v); abs != 0 && (abs < 1e-4 || abs >= 1e16) {
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".IN") {
+		s += ".0"
+	}
+	return s
+}
+
 func (o *Object) String() string {
 	if printableAttr, ok := o.Get("!printable"); ok {
 		if boolVal, ok := printableAttr.(bool); ok && !boolVal {
 			return ""
 		}
 	}
+	return o.PrintString()
+}
+
+func (o *Object) PrintString() string {
+	if hook, ok := o.Get("!printString"); ok {
+		if block, ok := hook.(Object); ok {
+			if fn, ok := block.properties["value"].(func(...Object) interface{}); ok {
+				if res, ok := fn(*o).(Object); ok && res.Class == "String" {
+					if s, ok := res.Self.(string); ok {
+						return s
+					}
+				}
+			}
+		}
+	}
+	return o.printString()
+}
 
+func (o *Object) DisplayString() string {
+	switch o.Class {
+	case "String":
+		if v, ok := o.Self.(string); ok {
+			return v
+		}
+	case "Symbol":
+		if v, ok := o.Self.(string); ok {
+			return strings.Trim(v, "\"'")
+		}
+	case "Character":
+		if v, ok := o.Self.(rune); ok {
+			return string(v)
+		}
+	}
+	return o.PrintString()
+}
+
+func (o *Object) printString() string {
 	switch o.Class {
 	case "Integer":
 		if v, ok := o.Self.(int64); ok {
@@ -125,7 +228,7 @@ func (o *Object) String() string {
 		}
 	case "Float":
 		if v, ok := o.Self.(float64); ok {
-			return fmt.Sprintf("%.10f", v)
+			return FormatFloat(v)
 		}
 	case "Bool":
 		if v, ok := o.Self.(bool); ok {
@@ -141,6 +244,9 @@ func (o *Object) String() string {
 		}
 	case "Character":
 		if v, ok := o.Self.(rune); ok {
+			if !unicode.IsPrint(v) {
+				return fmt.Sprintf("Character value: %d", v)
+			}
 			return fmt.Sprintf("$%s", string(v))
 		}
 	case "String":
@@ -162,58 +268,22 @@ func (o *Object) String() string {
 				if obj == nil {
 					elems[i] = "nil"
 				} else {
-					elems[i] = obj.String()
+					elems[i] = obj.PrintString()
 				}
 			}
 			return "#(" + strings.Join(elems, " ") + ")"
 		}
 	case "CodeBlock":
-		argsStr := ""
-		if argsVal, ok := o.Get("arguments"); ok {
-			if argsObj, ok := argsVal.(Object); ok {
-				if argsArr, ok := argsObj.Self.([]Object); ok {
-					argsParts := make([]string, len(argsArr))
-					for i, argObj := range argsArr {
-						argsParts[i] = argObj.String()
-					}
-					argsStr = strings.Join(argsParts, " ")
-				}
-			}
-		}
-
-		locStr := ""
-		if locVal, ok := o.Get("loc"); ok {
-			if locObj, ok := locVal.(Object); ok {
-				if locArr, ok := locObj.Self.([]Object); ok {
-					for i, lineObj := range locArr {
-						lineNum := i + 1
-						locStr += fmt.Sprintf("\n%d: ", lineNum)
-
-						if lineElems, ok := lineObj.Self.([]Object); ok {
-							elemStrs := make([]string, len(lineElems))
-							for j, elemObj := range lineElems {
-								if elemArr, ok := elemObj.Self.([]Object); ok && len(elemArr) == 2 {
-									classStr := elemArr[0].String()
-									valueStr := elemArr[1].String()
-									elemStrs[j] = fmt.Sprintf("{%s %s}", classStr, valueStr)
-								} else {
-									elemStrs[j] = "{}"
-								}
-							}
-							locStr += strings.Join(elemStrs, " ")
-						}
-					}
-				}
-			}
-		}
-
-		return argsStr + locStr
+		return blockSource(o)
 	default:
 		if strings.HasSuffix(o.Class, "Error") {
 			if msg, ok := o.Self.(string); ok {
 				return fmt.Sprintf("%s: %s", o.Class, msg)
 			}
 		}
+		if s, ok := o.Self.(fmt.Stringer); ok {
+			return s.String()
+		}
 		if o.Self != nil {
 			return fmt.Sprintf("<%s at %p>", o.Class, o)
 		}
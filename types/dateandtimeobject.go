@@ -0,0 +1,101 @@
+package types
+
+import (
+	"time"
+
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+type DateAndTimeObject struct {
+	core.Object
+}
+
+type dateAndTime struct {
+	t time.Time
+}
+
+func (d *dateAndTime) String() string {
+	return d.t.Format(time.RFC3339)
+}
+
+func NewDateAndTimeObject(value time.Time) *DateAndTimeObject {
+	obj := core.NewObject(&dateAndTime{value}, "DateAndTime")
+
+	compare := func(other core.Object) (int, bool) {
+		if other.Class != "DateAndTime" {
+			return 0, false
+		}
+		return value.Compare(other.Self.(*dateAndTime).t), true
+	}
+	obj.Set("lt", func(other core.Object) interface{} {
+		if c, ok := compare(other); ok {
+			return NewBoolObject(c < 0).Object
+		}
+		return nil
+	})
+	obj.Set("gt", func(other core.Object) interface{} {
+		if c, ok := compare(other); ok {
+			return NewBoolObject(c > 0).Object
+		}
+		return nil
+	})
+	obj.Set("le", func(other core.Object) interface{} {
+		if c, ok := compare(other); ok {
+			return NewBoolObject(c <= 0).Object
+		}
+		return nil
+	})
+	obj.Set("ge", func(other core.Object) interface{} {
+		if c, ok := compare(other); ok {
+			return NewBoolObject(c >= 0).Object
+		}
+		return nil
+	})
+	obj.Set("eq", func(other core.Object) interface{} {
+		if c, ok := compare(other); ok {
+			return NewBoolObject(c == 0).Object
+		}
+		return nil
+	})
+	obj.Set("minus", func(other core.Object) interface{} {
+		if other.Class != "DateAndTime" {
+			return nil
+		}
+		return NewFloatObject(value.Sub(other.Self.(*dateAndTime).t).Seconds()).Object
+	})
+	obj.Set("year", int64(value.Year()), ObjectConstructor)
+	obj.Set("month", int64(value.Month()), ObjectConstructor)
+	obj.Set("day", int64(value.Day()), ObjectConstructor)
+	obj.Set("hour", int64(value.Hour()), ObjectConstructor)
+	obj.Set("minute", int64(value.Minute()), ObjectConstructor)
+	obj.Set("second", int64(value.Second()), ObjectConstructor)
+	obj.Set("dayOfWeekName", value.Weekday().String(), ObjectConstructor)
+	obj.Set("asUnixTime", value.Unix(), ObjectConstructor)
+	obj.Set("asUTC", func() core.Object { return NewDateAndTimeObject(value.UTC()).Object })
+	obj.Set("asLocal", func() core.Object { return NewDateAndTimeObject(value.Local()).Object })
+	obj.Set("toInteger", value.Unix(), ObjectConstructor)
+	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
+	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
+	obj.Set("toSymbol", errors.NewTypeError("Invalid conversion to Symbol").Object)
+	obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
+	obj.Set("toString", value.Format(time.RFC3339), ObjectConstructor)
+	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
+	obj.Set("toArray", errors.NewTypeError("Invalid conversion to Array").Object)
+
+	return &DateAndTimeObject{*obj}
+}
+
+func NewDateAndTimeClass() *core.Object {
+	obj := core.NewObject("", "DateAndTime class")
+
+	obj.Set("now", func() core.Object { return NewDateAndTimeObject(time.Now()).Object })
+	obj.Set("fromUnixTime", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		return NewDateAndTimeObject(time.Unix(other.Self.(int64), 0).UTC()).Object
+	})
+
+	return obj
+}
@@ -0,0 +1,201 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+type DictionaryObject struct {
+	core.Object
+}
+
+type dictionary struct {
+	order   []string
+	keys    map[string]*core.Object
+	entries map[string]*core.Object
+}
+
+func dictionaryKey(key core.Object) string {
+	return fmt.Sprintf("%s:%v", key.Class, key.Self)
+}
+
+func (d *dictionary) lookup(key core.Object) (*core.Object, bool) {
+	val, ok := d.entries[dictionaryKey(key)]
+	return val, ok
+}
+
+func (d *dictionary) put(key core.Object, val *core.Object) {
+	k := dictionaryKey(key)
+	if _, ok := d.entries[k]; !ok {
+		d.order = append(d.order, k)
+	}
+	d.keys[k] = &key
+	d.entries[k] = val
+}
+
+func (d *dictionary) remove(key core.Object) (*core.Object, bool) {
+	k := dictionaryKey(key)
+	val, ok := d.entries[k]
+	if !ok {
+		return nil, false
+	}
+	delete(d.keys, k)
+	delete(d.entries, k)
+	for i, o := range d.order {
+		if o == k {
+			d.order = append(d.order[:i], d.order[i+1:]...)
+			break
+		}
+	}
+	return val, true
+}
+
+func (d *dictionary) String() string {
+	parts := make([]string, len(d.order))
+	for i, k := range d.order {
+		parts[i] = d.keys[k].PrintString() + "->" + d.entries[k].PrintString()
+	}
+	return "a Dictionary(" + strings.Join(parts, " ") + ")"
+}
+
+func NewDictionaryObject() *DictionaryObject {
+	dict := &dictionary{keys: make(map[string]*core.Object), entries: make(map[string]*core.Object)}
+	obj := core.NewObject(dict, "Dictionary")
+
+	obj.SetOptional("at", "put", core.NewObject(nil, ""))
+	obj.SetOptional("at", "ifAbsent", core.NewObject(nil, ""))
+
+	obj.Set("at", func(other core.Object) interface{} {
+		putVal, _ := obj.GetOptional("at", "put")
+		if putVal.Class != "" {
+			dict.put(other, putVal)
+			return *obj
+		}
+
+		if val, ok := dict.lookup(other); ok {
+			return *val
+		}
+
+		absentVal, _ := obj.GetOptional("at", "ifAbsent")
+		if absentVal.Class == "CodeBlock" {
+			noArgsVal, ok := absentVal.Get("no_arguments")
+			if !ok {
+				return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+			}
+			if noArgs, ok := noArgsVal.(int64); !ok || noArgs != 0 {
+				return errors.NewValueError("CodeBlock must have no arguments").Object
+			}
+			valFn, ok := absentVal.Get("value")
+			if !ok {
+				return errors.NewValueError("CodeBlock missing value attribute").Object
+			}
+			callable, ok := valFn.(func(...core.Object) interface{})
+			if !ok {
+				return errors.NewValueError("Invalid code block value").Object
+			}
+			return callable()
+		}
+		return errors.NewValueError(fmt.Sprintf("Key %s not found", other.PrintString())).Object
+	})
+	obj.Set("removeKey", func(other core.Object) interface{} {
+		val, ok := dict.remove(other)
+		if !ok {
+			return errors.NewValueError(fmt.Sprintf("Key %s not found", other.PrintString())).Object
+		}
+		return *val
+	})
+	obj.Set("includesKey", func(other core.Object) interface{} {
+		_, ok := dict.lookup(other)
+		return NewBoolObject(ok).Object
+	})
+	obj.Set("size", func() core.Object { return NewIntegerObject(int64(len(dict.order))).Object })
+	obj.Set("isEmpty", func() core.Object { return NewBoolObject(len(dict.order) == 0).Object })
+	obj.Set("keys", func() core.Object {
+		keys := make([]*core.Object, len(dict.order))
+		for i, k := range dict.order {
+			keys[i] = dict.keys[k]
+		}
+		return NewArrayObject(keys).Object
+	})
+	obj.Set("values", func() core.Object {
+		values := make([]*core.Object, len(dict.order))
+		for i, k := range dict.order {
+			values[i] = dict.entries[k]
+		}
+		return NewArrayObject(values).Object
+	})
+	obj.Set("do", func(other core.Object) interface{} {
+		if other.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := other.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		noArgs, ok := noArgsVal.(int64)
+		if !ok || (noArgs != 1 && noArgs != 2) {
+			return errors.NewValueError("CodeBlock must have 1 or 2 arguments").Object
+		}
+		valFnVal, ok := other.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFnVal.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+
+		for _, k := range append([]string{}, dict.order...) {
+			if noArgs == 1 {
+				callable(*dict.entries[k])
+			} else {
+				result := callable(*dict.keys[k])
+				nextBlock, ok := result.(core.Object)
+				if !ok || nextBlock.Class != "CodeBlock" {
+					continue
+				}
+				valFn2Val, ok := nextBlock.Get("value")
+				if !ok {
+					continue
+				}
+				callable2, ok := valFn2Val.(func(...core.Object) interface{})
+				if !ok {
+					continue
+				}
+				callable2(*dict.entries[k])
+			}
+		}
+
+		returnObj := NewBoolObject(true).Object
+		returnObj.Set("!printable", false)
+		return returnObj
+	})
+	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to Integer").Object)
+	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
+	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
+	obj.Set("toSymbol", errors.NewTypeError("Invalid conversion to Symbol").Object)
+	obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
+	obj.Set("toString", func() core.Object { return NewStringObject(obj.String()).Object })
+	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
+	obj.Set("toArray", func() core.Object {
+		values, _ := obj.Get("values")
+		return values.(func() core.Object)()
+	})
+
+	return &DictionaryObject{*obj}
+}
+
+func (d *DictionaryObject) AtPut(key core.Object, val core.Object) {
+	d.Self.(*dictionary).put(key, &val)
+}
+
+func NewDictionaryClass() *core.Object {
+	obj := core.NewObject("", "Dictionary class")
+
+	obj.Set("new", func() core.Object { return NewDictionaryObject().Object })
+
+	return obj
+}
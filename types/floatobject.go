@@ -1,8 +1,6 @@
 package types
 
 import (
-	"fmt"
-
 	"minitalk/types/core"
 	"minitalk/types/errors"
 )
@@ -146,7 +144,7 @@ func NewFloatObject(value float64) *FloatObject {
 	} else {
 		obj.Set("toCharacter", rune(value), ObjectConstructor)
 	}
-	obj.Set("toString", fmt.Sprintf("%.10f", value), ObjectConstructor)
+	obj.Set("toString", core.FormatFloat(value), ObjectConstructor)
 	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
 	obj.Set("toArray", errors.NewTypeError("Invalid conversion to Array").Object)
 
@@ -0,0 +1,296 @@
+package types
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+type RegexObject struct {
+	core.Object
+}
+
+type RegexMatchObject struct {
+	core.Object
+}
+
+type regex struct {
+	re *regexp.Regexp
+}
+
+func (r *regex) String() string {
+	return "a Regex(" + NewStringObject(r.re.String()).PrintString() + ")"
+}
+
+type regexMatch struct {
+	text string
+}
+
+func (m *regexMatch) String() string {
+	return "a RegexMatch(" + NewStringObject(m.text).PrintString() + ")"
+}
+
+const regexCacheSize = 64
+
+// regexLRU keeps the regexCacheSize patterns used last, so that patterns
+// built in a loop do not pile up.
+type regexLRU struct {
+	order *list.List
+	items map[string]*list.Element
+}
+
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRegexLRU() *regexLRU {
+	return &regexLRU{order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (c *regexLRU) get(pattern string) (*regexp.Regexp, bool) {
+	elem, ok := c.items[pattern]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(regexEntry).re, true
+}
+
+func (c *regexLRU) put(pattern string, re *regexp.Regexp) {
+	c.items[pattern] = c.order.PushFront(regexEntry{pattern, re})
+	if c.order.Len() > regexCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(regexEntry).pattern)
+	}
+}
+
+var (
+	regexCacheMu  sync.Mutex
+	regexCache    = newRegexLRU()
+	anchoredCache = newRegexLRU()
+)
+
+// unclosedAt answers the rune position of the innermost ( or [ of pattern
+// that is never closed, or the end of the pattern when there is none.
+func unclosedAt(pattern string, code syntax.ErrorCode) int {
+	runes := []rune(pattern)
+	parens := []int{}
+	class := -1
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '\\':
+			i++
+		case class >= 0:
+			if c == '[' && i+1 < len(runes) && runes[i+1] == ':' {
+				for j := i + 2; j+1 < len(runes); j++ {
+					if runes[j] == ':' && runes[j+1] == ']' {
+						i = j + 1
+						break
+					}
+				}
+			} else if c == ']' && i > class+1 && (i > class+2 || runes[class+1] != '^') {
+				class = -1
+			}
+		case c == '[':
+			class = i
+		case c == '(':
+			parens = append(parens, i)
+		case c == ')' && len(parens) > 0:
+			parens = parens[:len(parens)-1]
+		}
+	}
+	if code == syntax.ErrMissingBracket && class >= 0 {
+		return class
+	}
+	if code == syntax.ErrMissingParen && len(parens) > 0 {
+		return parens[len(parens)-1]
+	}
+	return len(runes)
+}
+
+func compileRegex(pattern string) (*regexp.Regexp, *core.Object) {
+	regexCacheMu.Lock()
+	defer regexCacheMu.Unlock()
+	if re, ok := regexCache.get(pattern); ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		msg := err.Error()
+		pos := 0
+		if serr, ok := err.(*syntax.Error); ok {
+			msg = serr.Code.String()
+			if serr.Code == syntax.ErrMissingParen || serr.Code == syntax.ErrMissingBracket {
+				pos = unclosedAt(pattern, serr.Code)
+			} else if idx := strings.LastIndex(pattern, serr.Expr); idx >= 0 {
+				pos = utf8.RuneCountInString(pattern[:idx])
+			}
+		}
+		return nil, &errors.NewValueError(fmt.Sprintf("Invalid regex at position %d: %s", pos, msg)).Object
+	}
+	regexCache.put(pattern, re)
+	return re, nil
+}
+
+// anchoredRegex answers re constrained to match its whole input. Go answers
+// the leftmost-first match, so testing the bounds of FindStringIndex would
+// reject inputs that a longer alternative matches completely.
+func anchoredRegex(re *regexp.Regexp) *regexp.Regexp {
+	regexCacheMu.Lock()
+	defer regexCacheMu.Unlock()
+	pattern := re.String()
+	if anchored, ok := anchoredCache.get(pattern); ok {
+		return anchored
+	}
+	anchored := regexp.MustCompile(`\A(?:` + pattern + `)\z`)
+	anchoredCache.put(pattern, anchored)
+	return anchored
+}
+
+func regexArgument(other core.Object) (*regexp.Regexp, *core.Object, bool) {
+	switch other.Class {
+	case "Regex":
+		return other.Self.(*regex).re, nil, true
+	case "String":
+		re, errObj := compileRegex(other.Self.(string))
+		return re, errObj, true
+	}
+	return nil, nil, false
+}
+
+func runeOffset(s string, byteIdx int) int64 {
+	return int64(utf8.RuneCountInString(s[:byteIdx]))
+}
+
+func regexMatches(re *regexp.Regexp, value string) core.Object {
+	found := re.FindAllString(value, -1)
+	arr := make([]*core.Object, len(found))
+	for i, m := range found {
+		arr[i] = &NewStringObject(m).Object
+	}
+	return NewArrayObject(arr).Object
+}
+
+func regexSplit(re *regexp.Regexp, value string) core.Object {
+	parts := re.Split(value, -1)
+	arr := make([]*core.Object, len(parts))
+	for i, p := range parts {
+		arr[i] = &NewStringObject(p).Object
+	}
+	return NewArrayObject(arr).Object
+}
+
+func NewRegexObject(re *regexp.Regexp) *RegexObject {
+	obj := core.NewObject(&regex{re}, "Regex")
+
+	obj.Set("pattern", re.String(), ObjectConstructor)
+	obj.Set("search", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		value := other.Self.(string)
+		loc := re.FindStringSubmatchIndex(value)
+		if loc == nil {
+			return *core.NewObject(nil, "Nil")
+		}
+		return NewRegexMatchObject(re, value, loc).Object
+	})
+	obj.Set("matches", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return NewBoolObject(anchoredRegex(re).MatchString(other.Self.(string))).Object
+	})
+	obj.Set("allMatches", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return regexMatches(re, other.Self.(string))
+	})
+	obj.Set("split", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return regexSplit(re, other.Self.(string))
+	})
+	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to Integer").Object)
+	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
+	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
+	obj.Set("toSymbol", errors.NewTypeError("Invalid conversion to Symbol").Object)
+	obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
+	obj.Set("toString", re.String(), ObjectConstructor)
+	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
+	obj.Set("toArray", errors.NewTypeError("Invalid conversion to Array").Object)
+
+	return &RegexObject{*obj}
+}
+
+func NewRegexMatchObject(re *regexp.Regexp, value string, loc []int) *RegexMatchObject {
+	group := func(i int) core.Object {
+		if loc[2*i] < 0 {
+			return *core.NewObject(nil, "Nil")
+		}
+		return NewStringObject(value[loc[2*i]:loc[2*i+1]]).Object
+	}
+	obj := core.NewObject(&regexMatch{value[loc[0]:loc[1]]}, "RegexMatch")
+
+	obj.Set("start", runeOffset(value, loc[0]), ObjectConstructor)
+	obj.Set("end", runeOffset(value, loc[1]), ObjectConstructor)
+	obj.Set("match", value[loc[0]:loc[1]], ObjectConstructor)
+	obj.Set("subexpression", func(other core.Object) interface{} {
+		idx := -1
+		switch other.Class {
+		case "Integer":
+			idx = int(other.Self.(int64))
+		case "String", "Symbol":
+			idx = re.SubexpIndex(other.DisplayString())
+			if idx < 0 {
+				return errors.NewValueError(fmt.Sprintf("No group named %s", other.DisplayString())).Object
+			}
+		default:
+			return nil
+		}
+		if idx < 0 || idx > re.NumSubexp() {
+			return errors.NewValueError(fmt.Sprintf("Group %d out of range", idx)).Object
+		}
+		return group(idx)
+	})
+	obj.Set("subexpressions", func() core.Object {
+		arr := make([]*core.Object, re.NumSubexp())
+		for i := range arr {
+			g := group(i + 1)
+			arr[i] = &g
+		}
+		return NewArrayObject(arr).Object
+	})
+	obj.Set("namedGroups", func() core.Object {
+		dict := NewDictionaryObject()
+		for i, name := range re.SubexpNames() {
+			if name != "" {
+				g := group(i)
+				dict.Self.(*dictionary).put(NewStringObject(name).Object, &g)
+			}
+		}
+		return dict.Object
+	})
+	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to Integer").Object)
+	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
+	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
+	obj.Set("toSymbol", errors.NewTypeError("Invalid conversion to Symbol").Object)
+	obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
+	obj.Set("toString", value[loc[0]:loc[1]], ObjectConstructor)
+	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
+	obj.Set("toArray", errors.NewTypeError("Invalid conversion to Array").Object)
+
+	return &RegexMatchObject{*obj}
+}
@@ -0,0 +1,436 @@
+package types
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"minitalk/types/core"
+	"minitalk/types/errors"
+)
+
+type StreamObject struct {
+	core.Object
+}
+
+type Stream struct {
+	class string
+	kind  string
+	runes []rune
+	bytes []byte
+	elems []*core.Object
+	pos   int
+	fill  func() (string, bool)
+	sink  func(string)
+}
+
+func (s *Stream) String() string {
+	return "a " + s.class
+}
+
+func (s *Stream) Buffered() int {
+	return len(string(s.runes[s.pos:]))
+}
+
+func (s *Stream) Discard() {
+	s.runes = nil
+	s.pos = 0
+}
+
+func (s *Stream) size() int {
+	switch s.kind {
+	case "ByteArray":
+		return len(s.bytes)
+	case "Array":
+		return len(s.elems)
+	}
+	return len(s.runes)
+}
+
+func (s *Stream) ensure(n int) bool {
+	for s.size()-s.pos < n {
+		if s.fill == nil {
+			return false
+		}
+		chunk, ok := s.fill()
+		if !ok {
+			return false
+		}
+		if s.kind == "ByteArray" {
+			s.bytes = append(s.bytes, chunk...)
+		} else {
+			s.runes = append(s.runes, []rune(chunk)...)
+		}
+	}
+	return true
+}
+
+func (s *Stream) compact() {
+	if s.fill == nil || s.pos == 0 {
+		return
+	}
+	switch s.kind {
+	case "String":
+		s.runes = s.runes[s.pos:]
+	case "ByteArray":
+		s.bytes = s.bytes[s.pos:]
+	}
+	s.pos = 0
+}
+
+func (s *Stream) element(i int) core.Object {
+	switch s.kind {
+	case "ByteArray":
+		return NewIntegerObject(int64(s.bytes[i])).Object
+	case "Array":
+		if s.elems[i] == nil {
+			return *core.NewObject(nil, "Nil")
+		}
+		return *s.elems[i]
+	}
+	return NewCharacterObject(s.runes[i]).Object
+}
+
+func (s *Stream) collection(from, to int) core.Object {
+	switch s.kind {
+	case "ByteArray":
+		return NewByteArrayObject(append([]byte{}, s.bytes[from:to]...)).Object
+	case "Array":
+		return NewArrayObject(append([]*core.Object{}, s.elems[from:to]...)).Object
+	}
+	return NewStringObject(string(s.runes[from:to])).Object
+}
+
+func (s *Stream) matches(i int, other core.Object) bool {
+	switch s.kind {
+	case "ByteArray":
+		v, ok := other.Self.(int64)
+		return ok && v == int64(s.bytes[i])
+	case "Array":
+		if s.elems[i] == nil {
+			return other.Self == nil
+		}
+		eqMethod, ok := s.elems[i].Get("eq")
+		if !ok {
+			return false
+		}
+		callable, ok := eqMethod.(func(core.Object) interface{})
+		if !ok {
+			return false
+		}
+		res, ok := callable(other).(core.Object)
+		return ok && res.Self == true
+	}
+	r, ok := other.Self.(rune)
+	return ok && other.Class == "Character" && r == s.runes[i]
+}
+
+// place writes items to dst: at the end, or on a ReadWriteStream at the
+// position, over what is there, leaving the position after them.
+func place[T any](s *Stream, dst, items []T) []T {
+	if s.class != "ReadWriteStream" {
+		return append(dst, items...)
+	}
+	end := s.pos + len(items)
+	if end > len(dst) {
+		dst = append(dst, make([]T, end-len(dst))...)
+	}
+	copy(dst[s.pos:], items)
+	s.pos = end
+	return dst
+}
+
+func (s *Stream) write(str string) {
+	if s.sink != nil {
+		s.sink(str)
+		return
+	}
+	s.runes = place(s, s.runes, []rune(str))
+}
+
+func (s *Stream) put(other core.Object) bool {
+	switch s.kind {
+	case "ByteArray":
+		v, ok := other.Self.(int64)
+		if !ok || other.Class != "Integer" || v < 0 || v > 255 {
+			return false
+		}
+		s.bytes = place(s, s.bytes, []byte{byte(v)})
+	case "Array":
+		s.elems = place(s, s.elems, []*core.Object{&other})
+	default:
+		r, ok := other.Self.(rune)
+		if !ok || other.Class != "Character" {
+			return false
+		}
+		s.write(string(r))
+	}
+	return true
+}
+
+func (s *Stream) putAll(other core.Object) bool {
+	switch s.kind {
+	case "ByteArray":
+		data, ok := other.Self.([]byte)
+		if !ok || other.Class != "ByteArray" {
+			return false
+		}
+		s.bytes = place(s, s.bytes, data)
+	case "Array":
+		elements, ok := other.Self.([]*core.Object)
+		if !ok || other.Class != "Array" {
+			return false
+		}
+		s.elems = place(s, s.elems, elements)
+	default:
+		if other.Class != "String" && other.Class != "Symbol" {
+			return false
+		}
+		s.write(other.DisplayString())
+	}
+	return true
+}
+
+func unprintable() core.Object {
+	returnObj := NewBoolObject(true).Object
+	returnObj.Set("!printable", false)
+	return returnObj
+}
+
+func (s *Stream) addReadProtocol(obj *core.Object) {
+	obj.Set("atEnd", func() core.Object { return NewBoolObject(!s.ensure(1)).Object })
+	obj.Set("next", core.Overloaded{
+		Unary: func() core.Object {
+			s.compact()
+			if !s.ensure(1) {
+				return *core.NewObject(nil, "Nil")
+			}
+			s.pos++
+			return s.element(s.pos - 1)
+		},
+		Keyword: func(other core.Object) interface{} {
+			if other.Class != "Integer" {
+				return nil
+			}
+			n, _ := other.Self.(int64)
+			if n < 0 {
+				return errors.NewValueError("Count cannot be negative").Object
+			}
+			s.compact()
+			s.ensure(int(n))
+			start := s.pos
+			s.pos = min(s.pos+int(n), s.size())
+			return s.collection(start, s.pos)
+		},
+	})
+	obj.Set("peek", func() core.Object {
+		if !s.ensure(1) {
+			return *core.NewObject(nil, "Nil")
+		}
+		return s.element(s.pos)
+	})
+	obj.Set("skip", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		n, _ := other.Self.(int64)
+		if n > 0 {
+			s.ensure(int(n))
+		}
+		s.pos = max(0, min(s.pos+int(n), s.size()))
+		return unprintable()
+	})
+	obj.Set("upTo", func(other core.Object) interface{} {
+		s.compact()
+		for i := s.pos; s.ensure(i - s.pos + 1); i++ {
+			if s.matches(i, other) {
+				res := s.collection(s.pos, i)
+				s.pos = i + 1
+				return res
+			}
+		}
+		res := s.collection(s.pos, s.size())
+		s.pos = s.size()
+		return res
+	})
+	obj.Set("upToEnd", func() core.Object {
+		s.compact()
+		for s.ensure(s.size() - s.pos + 1) {
+		}
+		start := s.pos
+		s.pos = s.size()
+		return s.collection(start, s.pos)
+	})
+	if s.kind == "String" {
+		obj.Set("nextLine", func() core.Object {
+			if !s.ensure(1) {
+				return *core.NewObject(nil, "Nil")
+			}
+			upTo, _ := obj.Get("upTo")
+			line := upTo.(func(core.Object) interface{})(NewCharacterObject('\n').Object).(core.Object)
+			return NewStringObject(strings.TrimSuffix(line.Self.(string), "\r")).Object
+		})
+		obj.Set("nextNumber", func() core.Object {
+			s.compact()
+			for s.ensure(1) && unicode.IsSpace(s.runes[s.pos]) {
+				s.pos++
+			}
+			start := s.pos
+			for s.ensure(1) && strings.ContainsRune("0123456789+-.eE", s.runes[s.pos]) {
+				s.pos++
+			}
+			if start == s.pos {
+				if !s.ensure(1) {
+					return *core.NewObject(nil, "Nil")
+				}
+				// Skip the bad token so that a reading loop still advances.
+				for s.ensure(1) && !unicode.IsSpace(s.runes[s.pos]) {
+					s.pos++
+				}
+			}
+			text := string(s.runes[start:s.pos])
+			if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+				return NewIntegerObject(i).Object
+			}
+			if f, err := strconv.ParseFloat(text, 64); err == nil {
+				return NewFloatObject(f).Object
+			}
+			return errors.NewValueError(fmt.Sprintf("Invalid number %s", text)).Object
+		})
+	}
+}
+
+func (s *Stream) addWriteProtocol(obj *core.Object) {
+	obj.Set("nextPutAll", func(other core.Object) interface{} {
+		if !s.putAll(other) {
+			return nil
+		}
+		return unprintable()
+	})
+	obj.Set("nextPut", func(other core.Object) interface{} {
+		if !s.put(other) {
+			return nil
+		}
+		return unprintable()
+	})
+	if s.kind == "String" {
+		obj.Set("print", func(other core.Object) interface{} {
+			s.write(other.PrintString())
+			return unprintable()
+		})
+		obj.Set("nl", func() core.Object {
+			s.write("\n")
+			return unprintable()
+		})
+		obj.Set("tab", func() core.Object {
+			s.write("\t")
+			return unprintable()
+		})
+		obj.Set("space", func() core.Object {
+			s.write(" ")
+			return unprintable()
+		})
+	}
+}
+
+func AddReadProtocol(obj *core.Object, fill func() (string, bool)) *Stream {
+	s := &Stream{class: obj.Class, kind: "String", fill: fill}
+	s.addReadProtocol(obj)
+	return s
+}
+
+func AddWriteProtocol(obj *core.Object, sink func(string)) *Stream {
+	s := &Stream{class: obj.Class, kind: "String", sink: sink}
+	s.addWriteProtocol(obj)
+	return s
+}
+
+func NewBinaryReadStream(fill func() (string, bool)) *StreamObject {
+	stream := NewStreamObject("ReadStream", NewByteArrayObject([]byte{}).Object)
+	stream.Self.(*Stream).fill = fill
+	return stream
+}
+
+func NewStreamObject(class string, collection core.Object) *StreamObject {
+	s := &Stream{class: class, kind: collection.Class}
+	switch v := collection.Self.(type) {
+	case string:
+		s.kind = "String"
+		s.runes = []rune(v)
+	case []byte:
+		s.bytes = append([]byte{}, v...)
+	case []*core.Object:
+		s.elems = append([]*core.Object{}, v...)
+	}
+	obj := core.NewObject(s, class)
+
+	if class != "WriteStream" {
+		s.addReadProtocol(obj)
+		obj.Set("reset", func() core.Object {
+			s.pos = 0
+			return unprintable()
+		})
+	}
+	if class != "ReadStream" {
+		s.addWriteProtocol(obj)
+	}
+	if class == "WriteStream" {
+		obj.Set("reset", func() core.Object {
+			s.runes, s.bytes, s.elems = nil, nil, nil
+			return unprintable()
+		})
+	}
+	obj.Set("contents", func() core.Object { return s.collection(0, s.size()) })
+	obj.Set("toInteger", errors.NewTypeError("Invalid conversion to Integer").Object)
+	obj.Set("toFloat", errors.NewTypeError("Invalid conversion to Float").Object)
+	obj.Set("toBool", errors.NewTypeError("Invalid conversion to Bool").Object)
+	obj.Set("toSymbol", errors.NewTypeError("Invalid conversion to Symbol").Object)
+	obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
+	obj.Set("toString", func() core.Object { return NewStringObject(obj.String()).Object })
+	obj.Set("toByteArray", errors.NewTypeError("Invalid conversion to ByteArray").Object)
+	obj.Set("toArray", errors.NewTypeError("Invalid conversion to Array").Object)
+
+	return &StreamObject{*obj}
+}
+
+func NewStreamClass(class string) *core.Object {
+	obj := core.NewObject("", class+" class")
+
+	obj.Set("on", func(other core.Object) interface{} {
+		switch other.Class {
+		case "String", "Symbol", "Array", "ByteArray":
+		default:
+			return nil
+		}
+		collection := other
+		if other.Class == "Symbol" {
+			collection = NewStringObject(other.DisplayString()).Object
+		}
+		if class == "WriteStream" {
+			collection = emptyLike(collection)
+		}
+		return NewStreamObject(class, collection).Object
+	})
+	if class == "WriteStream" {
+		obj.Set("with", func(other core.Object) interface{} {
+			switch other.Class {
+			case "String", "Array", "ByteArray":
+				return NewStreamObject(class, other).Object
+			}
+			return nil
+		})
+	}
+
+	return obj
+}
+
+func emptyLike(collection core.Object) core.Object {
+	switch collection.Class {
+	case "ByteArray":
+		return NewByteArrayObject([]byte{}).Object
+	case "Array":
+		return NewArrayObject([]*core.Object{}).Object
+	}
+	return NewStringObject("").Object
+}
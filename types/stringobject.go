@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"minitalk/types/core"
 	"minitalk/types/errors"
@@ -16,6 +17,12 @@ type StringObject struct {
 func NewStringObject(value string) *StringObject {
 	obj := core.NewObject(value, "String")
 
+	obj.SetOptional("copyFrom", "to", core.NewObject(nil, ""))
+	obj.SetOptional("replaceAll", "with", core.NewObject(nil, ""))
+	obj.SetOptional("copyReplaceAll", "with", core.NewObject(nil, ""))
+	obj.SetOptional("padLeftTo", "with", core.NewObject(nil, ""))
+	obj.SetOptional("copyReplacingRegex", "with", core.NewObject(nil, ""))
+
 	obj.Set("plus", func(other core.Object) interface{} {
 		if other.Class != "String" {
 			return nil
@@ -67,6 +74,318 @@ func NewStringObject(value string) *StringObject {
 		}
 		return NewArrayObject(arr).Object
 	})
+	obj.Set("size", func() core.Object { return NewIntegerObject(int64(len([]rune(value)))).Object })
+	obj.Set("isEmpty", value == "", ObjectConstructor)
+	obj.Set("at", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		runes := []rune(value)
+		idx, ok := other.Self.(int64)
+		if !ok || idx < 0 || idx >= int64(len(runes)) {
+			return errors.NewValueError(fmt.Sprintf("Index %d out of range", idx)).Object
+		}
+		return NewCharacterObject(runes[idx]).Object
+	})
+	obj.Set("do", func(other core.Object) interface{} {
+		if other.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, ok := other.Get("no_arguments")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing no_arguments attribute").Object
+		}
+		noArgs, ok := noArgsVal.(int64)
+		if !ok || (noArgs != 1 && noArgs != 2) {
+			return errors.NewValueError("CodeBlock must have 1 or 2 arguments").Object
+		}
+		valFnVal, ok := other.Get("value")
+		if !ok {
+			return errors.NewValueError("CodeBlock missing value attribute").Object
+		}
+		callable, ok := valFnVal.(func(...core.Object) interface{})
+		if !ok {
+			return errors.NewValueError("Invalid code block value").Object
+		}
+
+		for i, r := range []rune(value) {
+			if noArgs == 1 {
+				callable(NewCharacterObject(r).Object)
+			} else {
+				result := callable(NewIntegerObject(int64(i)).Object)
+				nextBlock, ok := result.(core.Object)
+				if !ok || nextBlock.Class != "CodeBlock" {
+					continue
+				}
+				valFn2Val, ok := nextBlock.Get("value")
+				if !ok {
+					continue
+				}
+				callable2, ok := valFn2Val.(func(...core.Object) interface{})
+				if !ok {
+					continue
+				}
+				callable2(NewCharacterObject(r).Object)
+			}
+		}
+
+		returnObj := NewBoolObject(true).Object
+		returnObj.Set("!printable", false)
+		return returnObj
+	})
+	obj.Set("copyFrom", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		runes := []rune(value)
+		start, _ := other.Self.(int64)
+		end := int64(len(runes)) - 1
+		if endObj, ok := obj.GetOptional("copyFrom", "to"); ok && endObj.Class != "" {
+			if endObj.Class != "Integer" {
+				return nil
+			}
+			end, _ = endObj.Self.(int64)
+		}
+		if start < 0 || start > int64(len(runes)) {
+			return errors.NewValueError(fmt.Sprintf("Index %d out of range", start)).Object
+		}
+		if end < start-1 || end >= int64(len(runes)) {
+			return errors.NewValueError(fmt.Sprintf("Index %d out of range", end)).Object
+		}
+		return NewStringObject(string(runes[start : end+1])).Object
+	})
+	obj.Set("indexOf", func(other core.Object) interface{} {
+		var needle string
+		switch other.Class {
+		case "Character":
+			needle = string(other.Self.(rune))
+		case "String":
+			needle = other.Self.(string)
+		default:
+			return nil
+		}
+		idx := strings.Index(value, needle)
+		if idx < 0 {
+			return NewIntegerObject(-1).Object
+		}
+		return NewIntegerObject(int64(utf8.RuneCountInString(value[:idx]))).Object
+	})
+	obj.Set("includesSubstring", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return NewBoolObject(strings.Contains(value, other.Self.(string))).Object
+	})
+	obj.Set("beginsWith", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return NewBoolObject(strings.HasPrefix(value, other.Self.(string))).Object
+	})
+	obj.Set("endsWith", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		return NewBoolObject(strings.HasSuffix(value, other.Self.(string))).Object
+	})
+	obj.Set("occurrencesOf", func(other core.Object) interface{} {
+		switch other.Class {
+		case "Character":
+			return NewIntegerObject(int64(strings.Count(value, string(other.Self.(rune))))).Object
+		case "String":
+			if other.Self.(string) == "" {
+				return NewIntegerObject(0).Object
+			}
+			return NewIntegerObject(int64(strings.Count(value, other.Self.(string)))).Object
+		}
+		return nil
+	})
+	obj.Set("asUppercase", func() core.Object { return NewStringObject(strings.ToUpper(value)).Object })
+	obj.Set("asLowercase", func() core.Object { return NewStringObject(strings.ToLower(value)).Object })
+	obj.Set("trimBoth", func() core.Object { return NewStringObject(strings.TrimSpace(value)).Object })
+	obj.Set("reversed", func() core.Object {
+		runes := []rune(value)
+		n := len(runes)
+		reversed := make([]rune, n)
+		for i, r := range runes {
+			reversed[n-1-i] = r
+		}
+		return NewStringObject(string(reversed)).Object
+	})
+	obj.Set("replaceAll", func(other core.Object) interface{} {
+		if other.Class != "Character" {
+			return nil
+		}
+		withObj, ok := obj.GetOptional("replaceAll", "with")
+		if !ok || withObj.Class != "Character" {
+			return errors.NewValueError("replaceAll: expects a Character for with:").Object
+		}
+		return NewStringObject(strings.ReplaceAll(value, string(other.Self.(rune)), string(withObj.Self.(rune)))).Object
+	})
+	obj.Set("copyReplaceAll", func(other core.Object) interface{} {
+		if other.Class != "String" {
+			return nil
+		}
+		withObj, ok := obj.GetOptional("copyReplaceAll", "with")
+		if !ok || withObj.Class != "String" {
+			return errors.NewValueError("copyReplaceAll: expects a String for with:").Object
+		}
+		if other.Self.(string) == "" {
+			return NewStringObject(value).Object
+		}
+		return NewStringObject(strings.ReplaceAll(value, other.Self.(string), withObj.Self.(string))).Object
+	})
+	obj.Set("lines", func() core.Object {
+		text := strings.ReplaceAll(value, "\r\n", "\n")
+		text = strings.TrimSuffix(text, "\n")
+		arr := []*core.Object{}
+		if text != "" {
+			for _, line := range strings.Split(text, "\n") {
+				arr = append(arr, &NewStringObject(line).Object)
+			}
+		}
+		return NewArrayObject(arr).Object
+	})
+	obj.Set("substrings", core.Overloaded{
+		Unary: func() core.Object {
+			fields := strings.Fields(value)
+			arr := make([]*core.Object, len(fields))
+			for i, f := range fields {
+				arr[i] = &NewStringObject(f).Object
+			}
+			return NewArrayObject(arr).Object
+		},
+		Keyword: func(other core.Object) interface{} {
+			var separators string
+			switch other.Class {
+			case "Character":
+				separators = string(other.Self.(rune))
+			case "String":
+				separators = other.Self.(string)
+			default:
+				return nil
+			}
+			fields := strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) })
+			arr := make([]*core.Object, len(fields))
+			for i, f := range fields {
+				arr[i] = &NewStringObject(f).Object
+			}
+			return NewArrayObject(arr).Object
+		},
+	})
+	obj.Set("join", func(other core.Object) interface{} {
+		if other.Class != "Array" {
+			return nil
+		}
+		elements := other.Self.([]*core.Object)
+		parts := make([]string, len(elements))
+		for i, el := range elements {
+			if el == nil {
+				parts[i] = "nil"
+			} else {
+				parts[i] = el.DisplayString()
+			}
+		}
+		return NewStringObject(strings.Join(parts, value)).Object
+	})
+	obj.Set("padLeftTo", func(other core.Object) interface{} {
+		if other.Class != "Integer" {
+			return nil
+		}
+		pad := ' '
+		if withObj, ok := obj.GetOptional("padLeftTo", "with"); ok && withObj.Class != "" {
+			if withObj.Class != "Character" {
+				return nil
+			}
+			pad = withObj.Self.(rune)
+		}
+		width, _ := other.Self.(int64)
+		missing := int(width) - utf8.RuneCountInString(value)
+		if missing <= 0 {
+			return NewStringObject(value).Object
+		}
+		return NewStringObject(strings.Repeat(string(pad), missing) + value).Object
+	})
+	obj.Set("format", func(other core.Object) interface{} {
+		if other.Class != "Array" && other.Class != "Dictionary" {
+			return nil
+		}
+		res, err := formatPlaceholders(value, other)
+		if err != nil {
+			return errors.NewValueError(err.Error()).Object
+		}
+		return NewStringObject(res).Object
+	})
+	obj.Set("mod", func(other core.Object) interface{} {
+		args := []*core.Object{&other}
+		if other.Class == "Array" {
+			args = other.Self.([]*core.Object)
+		}
+		res, err := formatPercent(value, args)
+		if err != nil {
+			return errors.NewValueError(err.Error()).Object
+		}
+		return NewStringObject(res).Object
+	})
+	obj.Set("asRegex", func() core.Object {
+		re, errObj := compileRegex(value)
+		if errObj != nil {
+			return *errObj
+		}
+		return NewRegexObject(re).Object
+	})
+	obj.Set("matchesRegex", func(other core.Object) interface{} {
+		re, errObj, ok := regexArgument(other)
+		if !ok {
+			return nil
+		}
+		if errObj != nil {
+			return *errObj
+		}
+		matches, _ := NewRegexObject(re).Get("matches")
+		return matches.(func(core.Object) interface{})(NewStringObject(value).Object)
+	})
+	obj.Set("allRegexMatches", func(other core.Object) interface{} {
+		re, errObj, ok := regexArgument(other)
+		if !ok {
+			return nil
+		}
+		if errObj != nil {
+			return *errObj
+		}
+		return regexMatches(re, value)
+	})
+	obj.Set("splitByRegex", func(other core.Object) interface{} {
+		re, errObj, ok := regexArgument(other)
+		if !ok {
+			return nil
+		}
+		if errObj != nil {
+			return *errObj
+		}
+		return regexSplit(re, value)
+	})
+	obj.Set("copyReplacingRegex", func(other core.Object) interface{} {
+		re, errObj, ok := regexArgument(other)
+		if !ok {
+			return nil
+		}
+		if errObj != nil {
+			return *errObj
+		}
+		withObj, ok := obj.GetOptional("copyReplacingRegex", "with")
+		if !ok || withObj.Class != "String" {
+			return errors.NewValueError("copyReplacingRegex: expects a String for with:").Object
+		}
+		return NewStringObject(re.ReplaceAllString(value, withObj.Self.(string))).Object
+	})
+	obj.Set("asSymbol", value, SymbolConstructor)
+	obj.Set("asByteArray", func() core.Object {
+		if idx := invalidUTF8Index([]byte(value)); idx >= 0 {
+			return errors.NewValueError(fmt.Sprintf("Cannot encode String as UTF-8: invalid byte at %d", idx)).Object
+		}
+		return NewByteArrayObject([]byte(value)).Object
+	})
 	if iVal, err := strconv.ParseInt(value, 10, 64); err == nil {
 		obj.Set("toInteger", iVal, ObjectConstructor)
 	} else {
@@ -82,8 +401,8 @@ func NewStringObject(value string) *StringObject {
 	} else {
 		obj.Set("toBool", errors.NewValueError(fmt.Sprintf("Cannot convert %s to Bool", value)).Object)
 	}
-	if len(value) == 1 {
-		obj.Set("toCharacter", rune(value[0]), ObjectConstructor)
+	if runes := []rune(value); len(runes) == 1 {
+		obj.Set("toCharacter", runes[0], ObjectConstructor)
 	} else {
 		obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
 	}
@@ -94,3 +413,172 @@ func NewStringObject(value string) *StringObject {
 
 	return &StringObject{*obj}
 }
+
+func NewStringClass() *core.Object {
+	obj := core.NewObject("", "String class")
+
+	obj.SetOptional("new", "withAll", core.NewObject(nil, ""))
+
+	obj.Set("new", core.Overloaded{
+		Unary: func() core.Object { return NewStringObject("").Object },
+		Keyword: func(other core.Object) interface{} {
+			if other.Class != "Integer" {
+				return nil
+			}
+			size, _ := other.Self.(int64)
+			if size < 0 {
+				return errors.NewValueError("Size cannot be negative").Object
+			}
+			fill := ' '
+			if fillObj, ok := obj.GetOptional("new", "withAll"); ok && fillObj.Class != "" {
+				if fillObj.Class != "Character" {
+					return nil
+				}
+				fill = fillObj.Self.(rune)
+			}
+			return NewStringObject(strings.Repeat(string(fill), int(size))).Object
+		},
+	})
+	obj.Set("streamContents", func(other core.Object) interface{} {
+		if other.Class != "CodeBlock" {
+			return nil
+		}
+		noArgsVal, _ := other.Get("no_arguments")
+		if noArgsVal.(int64) != 1 {
+			return errors.NewValueError("CodeBlock must have 1 argument").Object
+		}
+		stream := NewStreamObject("WriteStream", NewStringObject("").Object)
+		valFn, _ := other.Get("value")
+		result := valFn.(func(...core.Object) interface{})(stream.Object)
+		if res, ok := result.(core.Object); ok && strings.HasSuffix(res.Class, "Error") {
+			return res
+		}
+		contents, _ := stream.Get("contents")
+		return contents.(func() core.Object)()
+	})
+
+	return obj
+}
+
+func formatPlaceholders(format string, args core.Object) (string, error) {
+	var sb strings.Builder
+	for {
+		open := strings.IndexByte(format, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(format[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		sb.WriteString(format[:open])
+		name := format[open+1 : end]
+
+		var arg *core.Object
+		switch args.Class {
+		case "Array":
+			elements := args.Self.([]*core.Object)
+			idx, err := strconv.Atoi(name)
+			if err != nil || idx < 1 || idx > len(elements) {
+				return "", fmt.Errorf("Missing format argument {%s}", name)
+			}
+			arg = elements[idx-1]
+		case "Dictionary":
+			dict := args.Self.(*dictionary)
+			var ok bool
+			if arg, ok = dict.lookup(NewStringObject(name).Object); !ok {
+				if arg, ok = dict.lookup(NewSymbolObject(name).Object); !ok {
+					return "", fmt.Errorf("Missing format argument {%s}", name)
+				}
+			}
+		}
+		if arg == nil {
+			sb.WriteString("nil")
+		} else {
+			sb.WriteString(arg.DisplayString())
+		}
+		format = format[end+1:]
+	}
+	sb.WriteString(format)
+	return sb.String(), nil
+}
+
+func formatPercent(format string, args []*core.Object) (string, error) {
+	var sb strings.Builder
+	next := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			sb.WriteByte(format[i])
+			continue
+		}
+		start := i
+		i++
+		for i < len(format) && strings.IndexByte("-+ #0", format[i]) >= 0 {
+			i++
+		}
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+		if i < len(format) && format[i] == '.' {
+			i++
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+		if i >= len(format) {
+			return "", fmt.Errorf("Incomplete format specifier at %d", start)
+		}
+		verb := format[i]
+		spec := format[start:i]
+		if verb == '%' {
+			sb.WriteByte('%')
+			continue
+		}
+		if next >= len(args) {
+			return "", fmt.Errorf("Not enough arguments for format string")
+		}
+		arg := args[next]
+		next++
+		if arg == nil {
+			arg = core.NewObject(nil, "Nil")
+		}
+
+		switch verb {
+		case 'd', 'i', 'x', 'X', 'o', 'b':
+			n, ok := arg.Self.(int64)
+			if !ok || arg.Class != "Integer" {
+				return "", fmt.Errorf("%%%c expects an Integer, got %s", verb, arg.Class)
+			}
+			if verb == 'i' {
+				verb = 'd'
+			}
+			sb.WriteString(fmt.Sprintf(spec+string(verb), n))
+		case 'f', 'e', 'E', 'g':
+			var f float64
+			switch v := arg.Self.(type) {
+			case float64:
+				f = v
+			case int64:
+				f = float64(v)
+			default:
+				return "", fmt.Errorf("%%%c expects a number, got %s", verb, arg.Class)
+			}
+			sb.WriteString(fmt.Sprintf(spec+string(verb), f))
+		case 'c':
+			r, ok := arg.Self.(rune)
+			if !ok || arg.Class != "Character" {
+				return "", fmt.Errorf("%%c expects a Character, got %s", arg.Class)
+			}
+			sb.WriteString(fmt.Sprintf(spec+"c", r))
+		case 's':
+			sb.WriteString(fmt.Sprintf(spec+"s", arg.DisplayString()))
+		default:
+			return "", fmt.Errorf("Invalid format specifier %s%c", spec, verb)
+		}
+	}
+	if next < len(args) {
+		return "", fmt.Errorf("Too many arguments for format string")
+	}
+	return sb.String(), nil
+}
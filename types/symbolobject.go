@@ -34,8 +34,8 @@ func NewSymbolObject(name string) *SymbolObject {
 	} else {
 		obj.Set("toBool", errors.NewValueError(fmt.Sprintf("Cannot convert %s to Bool", name)).Object)
 	}
-	if len(name) == 1 {
-		obj.Set("toCharacter", rune(name[0]), ObjectConstructor)
+	if runes := []rune(name); len(runes) == 1 {
+		obj.Set("toCharacter", runes[0], ObjectConstructor)
 	} else {
 		obj.Set("toCharacter", errors.NewTypeError("Invalid conversion to Character").Object)
 	}
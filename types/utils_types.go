@@ -1,11 +1,17 @@
 package types
 
 import (
+	"unicode/utf8"
+
 	"minitalk/tokens"
 	"minitalk/types/core"
 	"minitalk/types/errors"
 )
 
+func init() {
+	core.Wrap = ObjectConstructor
+}
+
 func ObjectConstructor(val interface{}) *core.Object {
 	switch v := val.(type) {
 	case int64:
@@ -50,6 +56,17 @@ func convertToByteArray(arr []*core.Object) ([]byte, bool) {
 	return bytes, true
 }
 
+func invalidUTF8Index(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
+
 func StringToTokenType(s string) tokens.TokenType {
 	switch s {
 	case "Self":
@@ -90,6 +107,8 @@ func StringToTokenType(s string) tokens.TokenType {
 		return tokens.Slash
 	case "Ampersand":
 		return tokens.Ampersand
+	case "Percent":
+		return tokens.Percent
 	case "LessThan":
 		return tokens.LessThan
 	case "GreaterThan":
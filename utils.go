@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"os"
 	"runtime"
 
 	"minitalk/tokens"
@@ -54,6 +55,8 @@ func TokenTypeToString(t tokens.TokenType) string {
 		return "Slash"
 	case tokens.Ampersand:
 		return "Ampersand"
+	case tokens.Percent:
+		return "Percent"
 	case tokens.LessThan:
 		return "LessThan"
 	case tokens.GreaterThan:
@@ -106,3 +109,10 @@ func filterWhitespace(toks []tokens.Token) []tokens.Token {
 	}
 	return filtered
 }
+
+var syntaxErrors int
+
+func syntaxError(msg ...any) {
+	syntaxErrors++
+	fmt.Fprintln(os.Stderr, append([]any{"SyntaxError:"}, msg...)...)
+}